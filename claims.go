@@ -3,6 +3,7 @@ package multi
 import (
 	"errors"
 	"fmt"
+	"reflect"
 	"strconv"
 	"strings"
 	"time"
@@ -25,6 +26,85 @@ const (
 	ValidationErrorAuthType
 )
 
+// claims validation errors, a *ValidationError matches each of its failures by errors.Is.
+var (
+	ErrClaimsExpired              = errors.New("token is expired")
+	ErrClaimsMissingId            = errors.New("id is empty")
+	ErrClaimsMissingUsername      = errors.New("username is empty")
+	ErrClaimsMissingAuthorityId   = errors.New("authority id is empty")
+	ErrClaimsInvalidAuthorityType = errors.New("authority type is invalid")
+	ErrClaimsInvalidLoginType     = errors.New("login type is invalid")
+	ErrClaimsInvalidAuthType      = errors.New("auth type is invalid")
+)
+
+// ValidationError is returned by MultiClaims.Valid with all of the failures,
+// Errors keeps the bitmask of the failures, e.g. ValidationErrorExpired.
+// At is the time the claims were validated at.
+type ValidationError struct {
+	Errors uint32
+	Inner  []error
+	At     time.Time
+}
+
+// add
+func (e *ValidationError) add(flag uint32, err error) {
+	e.Errors |= flag
+	e.Inner = append(e.Inner, err)
+}
+
+// No errors
+func (e *ValidationError) valid() bool {
+	return e.Errors == 0
+}
+
+// without returns the failures except the flag and its error, nil is returned if none is left.
+func (e *ValidationError) without(flag uint32, target error) error {
+	rs := &ValidationError{Errors: e.Errors &^ flag, At: e.At}
+	for _, err := range e.Inner {
+		if !errors.Is(err, target) {
+			rs.Inner = append(rs.Inner, err)
+		}
+	}
+	if rs.valid() {
+		return nil
+	}
+	return rs
+}
+
+// Error
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Inner))
+	for _, err := range e.Inner {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Is reports whether any of the failures matches the target.
+func (e *ValidationError) Is(target error) bool {
+	for _, err := range e.Inner {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first failure that matches the target,
+// a *jwt.ValidationError target gets the same bitmask for compatibility.
+func (e *ValidationError) As(target interface{}) bool {
+	if jErr, ok := target.(**jwt.ValidationError); ok {
+		*jErr = &jwt.ValidationError{Inner: e, Errors: e.Errors}
+		return true
+	}
+	for _, err := range e.Inner {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
 // Custom struct
 // Id use, id
 // Username
@@ -34,6 +114,7 @@ const (
 // AuthorityType
 // LoginType  web,app,wechat
 // AuthType
+// Issuer
 // CreationDate
 // ExpiresIn
 type MultiClaims struct {
@@ -45,6 +126,7 @@ type MultiClaims struct {
 	AuthorityType int    `json:"authorityType,omitempty" redis:"authority_type"`
 	LoginType     int    `json:"loginType,omitempty" redis:"login_type"`
 	AuthType      int    `json:"authType,omitempty" redis:"auth_type"`
+	Issuer        string `json:"iss,omitempty" redis:"issuer"`
 	CreationDate  int64  `json:"creationData,omitempty" redis:"creation_data"`
 	ExpiresAt     int64  `json:"expiresAt,omitempty" redis:"expires_at"`
 }
@@ -59,58 +141,76 @@ func New(m *Multi) *MultiClaims {
 		AuthorityType: m.AuthorityType,
 		LoginType:     m.LoginType,
 		AuthType:      m.AuthType,
-		CreationDate:  time.Now().Local().Unix(),
+		Issuer:        m.Issuer,
+		CreationDate:  DefaultClock.Now().Local().Unix(),
 		ExpiresAt:     m.ExpiresAt,
 	}
 	return claims
 }
 
+// Standard returns the standard claims,
+// it is promoted to the custom claims which embed MultiClaims.
+func (c *MultiClaims) Standard() *MultiClaims {
+	return c
+}
+
+// newClaims returns a new zero claims of type T.
+func newClaims[T Claims]() T {
+	var cla T
+	if typ := reflect.TypeOf(cla); typ != nil && typ.Kind() == reflect.Ptr {
+		return reflect.New(typ.Elem()).Interface().(T)
+	}
+	return cla
+}
+
+// validAt validates the claims at the time t if they support it, otherwise by Valid.
+func validAt[T Claims](claims T, t time.Time) error {
+	if c, ok := any(claims).(interface{ ValidAt(time.Time) error }); ok {
+		return c.ValidAt(t)
+	}
+	return claims.Valid()
+}
+
+// Valid validates the claims at the time of DefaultClock, see ValidAt.
 func (c *MultiClaims) Valid() error {
-	vErr := new(jwt.ValidationError)
-	now := time.Now().Unix()
+	return c.ValidAt(DefaultClock.Now())
+}
+
+// ValidAt validates the claims at the given time, all of the failures are returned as a *ValidationError.
+func (c *MultiClaims) ValidAt(t time.Time) error {
+	vErr := &ValidationError{At: t}
+	now := t.Unix()
 	// The claims below are optional, by default, so if they are set to the
 	// default value in Go, let's not fail the verification for them.
 	if !c.VerifyExpiresAt(now, false) {
 		delta := time.Unix(now, 0).Sub(time.Unix(c.ExpiresAt, 0))
-		vErr.Inner = fmt.Errorf("token is expired by %v", delta)
-		vErr.Errors |= ValidationErrorExpired
+		vErr.add(ValidationErrorExpired, fmt.Errorf("%w by %v", ErrClaimsExpired, delta))
 	}
 	if !c.VerifyId() {
-		vErr.Inner = errors.New("id is empty")
-		vErr.Errors |= ValidationErrorId
+		vErr.add(ValidationErrorId, ErrClaimsMissingId)
 	}
 	if !c.VerifyUsername() {
-		vErr.Inner = errors.New("username is empty")
-		vErr.Errors |= ValidationErrorUsername
+		vErr.add(ValidationErrorUsername, ErrClaimsMissingUsername)
 	}
 	if !c.VerifyAuthorityId() {
-		vErr.Inner = errors.New("authority id is empty")
-		vErr.Errors |= ValidationErrorAuthorityId
+		vErr.add(ValidationErrorAuthorityId, ErrClaimsMissingAuthorityId)
 	}
 	if !c.VerifyAuthorityType() {
-		vErr.Inner = errors.New("authority type is invalid")
-		vErr.Errors |= ValidationErrorAuthorityType
+		vErr.add(ValidationErrorAuthorityType, ErrClaimsInvalidAuthorityType)
 	}
 	if !c.VerifyLoginType() {
-		vErr.Inner = errors.New("login type is invalid")
-		vErr.Errors |= ValidationErrorLoginType
+		vErr.add(ValidationErrorLoginType, ErrClaimsInvalidLoginType)
 	}
 	if !c.VerifyAuthType() {
-		vErr.Inner = errors.New("auth type is invalid")
-		vErr.Errors |= ValidationErrorAuthType
+		vErr.add(ValidationErrorAuthType, ErrClaimsInvalidAuthType)
 	}
-	if valid(vErr) {
+	if vErr.valid() {
 		return nil
 	}
 
 	return vErr
 }
 
-// No errors
-func valid(e *jwt.ValidationError) bool {
-	return e.Errors == 0
-}
-
 // Compares the exp claim against cmp.
 // If required is false, this method will return true if the value matches or is unset
 func (c *MultiClaims) VerifyExpiresAt(cmp int64, req bool) bool {
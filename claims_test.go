@@ -1,8 +1,12 @@
 package multi
 
 import (
+	"errors"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/golang-jwt/jwt"
 )
 
 func TestNew(t *testing.T) {
@@ -42,3 +46,108 @@ func TestValid(t *testing.T) {
 		}
 	})
 }
+
+func TestValidAggregated(t *testing.T) {
+	cla := New(&Multi{
+		Id:            uint(8457585),
+		AuthorityType: AdminAuthority,
+		LoginType:     LoginTypeWeb,
+		ExpiresAt:     time.Now().Local().Add(-time.Hour).Unix(),
+	})
+	err := cla.Valid()
+	t.Run("Test claims all failures", func(t *testing.T) {
+		for _, target := range []error{ErrClaimsExpired, ErrClaimsMissingUsername, ErrClaimsMissingAuthorityId} {
+			if !errors.Is(err, target) {
+				t.Errorf("claims valid error %v want match %v", err, target)
+			}
+		}
+		if errors.Is(err, ErrClaimsMissingId) {
+			t.Errorf("claims valid error %v should not match %v", err, ErrClaimsMissingId)
+		}
+	})
+	t.Run("Test claims bitmask", func(t *testing.T) {
+		var vErr *ValidationError
+		if !errors.As(err, &vErr) {
+			t.Fatalf("claims valid error %T want *ValidationError", err)
+		}
+		want := ValidationErrorExpired | ValidationErrorUsername | ValidationErrorAuthorityId
+		if vErr.Errors != want {
+			t.Errorf("claims valid bitmask want %b but get %b", want, vErr.Errors)
+		}
+		var jErr *jwt.ValidationError
+		if !errors.As(err, &jErr) || jErr.Errors != want {
+			t.Errorf("claims valid jwt error want bitmask %b but get %v", want, jErr)
+		}
+	})
+}
+
+type avatarClaims struct {
+	MultiClaims
+	Avatar string `json:"avatar,omitempty"`
+}
+
+func TestNewClaims(t *testing.T) {
+	t.Run("Test new custom claims", func(t *testing.T) {
+		cla := newClaims[*avatarClaims]()
+		if cla == nil {
+			t.Fatal("new claims return is nil")
+		}
+		if cla.Standard() != &cla.MultiClaims {
+			t.Error("standard claims is not the embedded claims")
+		}
+	})
+}
+
+// newTestClaims returns the valid claims of the admin user, changed by the options.
+func newTestClaims(id uint, opts ...func(*MultiClaims)) *MultiClaims {
+	claims := New(&Multi{
+		Id:            id,
+		Username:      "username",
+		AuthorityIds:  []string{"999"},
+		AuthorityType: AdminAuthority,
+		LoginType:     LoginTypeWeb,
+		AuthType:      AuthPwd,
+		ExpiresAt:     time.Now().Local().Add(RedisSessionTimeoutWeb).Unix(),
+	})
+	for _, opt := range opts {
+		opt(claims)
+	}
+	return claims
+}
+
+// withTenancy sets the tenancy of the claims.
+func withTenancy(tenancyId uint) func(*MultiClaims) {
+	return func(c *MultiClaims) {
+		c.TenancyId = tenancyId
+		c.TenancyName = "tenancy"
+	}
+}
+
+// withAuthorityType sets the authority type of the claims.
+func withAuthorityType(authorityType int) func(*MultiClaims) {
+	return func(c *MultiClaims) {
+		c.AuthorityType = authorityType
+	}
+}
+
+// withAuthorityIds sets the authority ids of the claims.
+func withAuthorityIds(authorityIds ...string) func(*MultiClaims) {
+	return func(c *MultiClaims) {
+		c.AuthorityId = strings.Join(authorityIds, AuthorityTypeSplit)
+	}
+}
+
+// withLoginType sets the login type of the claims.
+func withLoginType(loginType int) func(*MultiClaims) {
+	return func(c *MultiClaims) {
+		c.LoginType = loginType
+	}
+}
+
+// newAvatarTestClaims returns the custom claims of the user.
+func newAvatarTestClaims(id uint) *avatarClaims {
+	return &avatarClaims{
+		MultiClaims: *newTestClaims(id, withTenancy(1)),
+		Avatar:      "avatar.png",
+	}
+}
@@ -0,0 +1,63 @@
+package multi
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the current time to the claims validation and the drivers,
+// replace it by a FakeClock to control the expiry in tests.
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock
+type systemClock struct{}
+
+// Now
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// DefaultClock is used by New, MultiClaims.Valid, Leeway and the drivers without a Clock.
+var DefaultClock Clock = systemClock{}
+
+// clockOf returns the clock, or DefaultClock if it is nil.
+func clockOf(c Clock) Clock {
+	if c == nil {
+		return DefaultClock
+	}
+	return c
+}
+
+// FakeClock is a Clock which only moves when it is told to.
+type FakeClock struct {
+	mu  sync.RWMutex
+	now time.Time
+}
+
+// NewFakeClock returns the fake clock stopped at now.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now
+func (c *FakeClock) Now() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Set moves the clock to now.
+func (c *FakeClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
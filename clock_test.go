@@ -0,0 +1,128 @@
+package multi
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFakeClock(t *testing.T) {
+	now := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(now)
+	clock.Advance(time.Hour)
+	if got := clock.Now(); !got.Equal(now.Add(time.Hour)) {
+		t.Errorf("fake clock want %v but get %v", now.Add(time.Hour), got)
+	}
+	clock.Set(now)
+	if got := clock.Now(); !got.Equal(now) {
+		t.Errorf("fake clock want %v but get %v", now, got)
+	}
+}
+
+func TestClaimsValidClock(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	DefaultClock = clock
+	defer func() { DefaultClock = systemClock{} }()
+
+	cla := newTestClaims(999)
+	cla.ExpiresAt = clock.Now().Add(time.Minute).Unix()
+	if err := cla.Valid(); err != nil {
+		t.Fatalf("claims valid want nil but get %v", err)
+	}
+	clock.Advance(2 * time.Minute)
+	if err := cla.Valid(); !errors.Is(err, ErrClaimsExpired) {
+		t.Errorf("claims valid want %v but get %v", ErrClaimsExpired, err)
+	}
+	if err := ValidateClaims(nil, cla, Leeway(2*time.Minute)); err != nil {
+		t.Errorf("validate claims within leeway want nil but get %v", err)
+	}
+}
+
+func TestLocalAuthClock(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	auth := NewLocalAuth()
+	auth.Clock = clock
+	token, _, err := auth.GenerateToken(newTestClaims(999))
+	if err != nil {
+		t.Fatalf("generate token %v", err)
+	}
+	t.Run("test session alive", func(t *testing.T) {
+		clock.Advance(RedisSessionTimeoutWeb - time.Minute)
+		if _, err := auth.GetMultiClaims(token); err != nil {
+			t.Errorf("get multi claims want nil but get %v", err)
+		}
+	})
+	t.Run("test session refreshed", func(t *testing.T) {
+		if err := auth.UpdateUserTokenCacheExpire(token); err != nil {
+			t.Fatalf("update user token cache expire %v", err)
+		}
+		clock.Advance(2 * time.Minute)
+		if _, err := auth.GetMultiClaims(token); err != nil {
+			t.Errorf("get multi claims want nil but get %v", err)
+		}
+	})
+	t.Run("test session expired", func(t *testing.T) {
+		clock.Advance(RedisSessionTimeoutWeb)
+		if _, err := auth.GetMultiClaims(token); !errors.Is(err, ErrTokenInvalid) {
+			t.Errorf("get multi claims want %v but get %v", ErrTokenInvalid, err)
+		}
+		if count := auth.getUserTokenCount(AdminAuthority, "999"); count != 0 {
+			t.Errorf("user token count want 0 but get %d", count)
+		}
+	})
+}
+
+func TestJwtAuthClock(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	auth := NewJwtAuth(nil)
+	auth.Clock = clock
+	// the token is issued a second before the revocation.
+	token, _, err := auth.GenerateToken(newTestClaims(999))
+	if err != nil {
+		t.Fatalf("generate token %v", err)
+	}
+	clock.Advance(time.Second)
+	if err := auth.CleanAllUserTokenCache("999"); err != nil {
+		t.Fatalf("clean all user token cache %v", err)
+	}
+	if _, expiration, found := auth.Revoked.GetWithExpiration(getUserIdPrefixKey("999")); !found || expiration.IsZero() {
+		t.Errorf("revocation want expiration but get %v %v", expiration, found)
+	}
+	if _, err := auth.GetMultiClaims(token); !errors.Is(err, ErrTokenInvalid) {
+		t.Errorf("get multi claims want %v but get %v", ErrTokenInvalid, err)
+	}
+	// the new token is issued in the same second as the revocation.
+	fresh, _, err := auth.GenerateToken(newTestClaims(999))
+	if err != nil {
+		t.Fatalf("generate token %v", err)
+	}
+	if _, err := auth.GetMultiClaims(fresh); err != nil {
+		t.Errorf("get multi claims of the new token want nil but get %v", err)
+	}
+}
+
+func TestJwtAuthClockExpiry(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	auth := NewJwtAuth(nil)
+	auth.Clock = clock
+	token, _, err := auth.GenerateToken(newTestClaims(998))
+	if err != nil {
+		t.Fatalf("generate token %v", err)
+	}
+	clock.Advance(RedisSessionTimeoutWeb + time.Hour)
+	_, err = auth.GetMultiClaims(token)
+	if !errors.Is(err, ErrClaimsExpired) {
+		t.Errorf("get multi claims want %v but get %v", ErrClaimsExpired, err)
+	}
+	var vErr *ValidationError
+	if !errors.As(err, &vErr) || !vErr.At.Equal(clock.Now()) {
+		t.Errorf("validation error want the time of the driver clock but get %v", err)
+	}
+	if _, err := auth.IsRole(token, AdminAuthority); !errors.Is(err, ErrClaimsExpired) {
+		t.Errorf("is role want %v but get %v", ErrClaimsExpired, err)
+	}
+	auth.Leeway = 2 * time.Hour
+	if _, err := auth.GetMultiClaims(token); err != nil {
+		t.Errorf("leeway of the driver clock want nil but get %v", err)
+	}
+}
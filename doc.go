@@ -26,6 +26,9 @@ options := &redis.UniversalOptions{
 		panic(err)
 	}
 
+	// notify the websocket connections of this instance when other instances revoke their sessions
+	go multi.AuthDriver.(*multi.RedisAuth).SubscribeRevoked(context.Background())
+
 ======== for local driver ==============
 	err := multi.InitDriver(&multi.Config{
 		DriverType:      "local"
@@ -41,6 +44,81 @@ options := &redis.UniversalOptions{
 	if err != nil {
 		panic(err)
 	}
+
+======== for custom claims ==============
+	type MyClaims struct {
+		multi.MultiClaims
+		Avatar string `json:"avatar,omitempty"`
+	}
+
+	driver, err := multi.NewDriver[*MyClaims](&multi.Config{
+		DriverType:      "redis",
+		TokenMaxCount:   10,
+		UniversalClient: redis.NewUniversalClient(options)})
+	if err != nil {
+		panic(err)
+	}
+	verifier := multi_gin.NewVerifierOf[*MyClaims](driver)
+	// in handler: claims := multi_gin.GetClaims[*MyClaims](ctx)
+
+======== for builtin validators ==============
+	blocklist := multi.NewRedisBlocklist(redis.NewUniversalClient(options))
+	verifier := multi_gin.NewVerifier(
+		blocklist,
+		multi.Expected{TenancyId: 1, LoginTypes: []int{multi.LoginTypeWeb}},
+		multi.Leeway(5*time.Second),
+	)
+	// reject the token until it expires: blocklist.Block(token, multi.RedisSessionTimeoutWeb)
+	// the jwt driver checks the expiry itself, its leeway is set by multi.Config{JwtLeeway: 5 * time.Second}
+
+======== for signed opaque tokens ==============
+	generator, err := multi.NewSignedTokenGenerator([]byte("your 32 bytes token signing key."))
+	if err != nil {
+		panic(err)
+	}
+	err = multi.InitDriver(&multi.Config{
+		DriverType:      "redis",
+		UniversalClient: redis.NewUniversalClient(options),
+		TokenGenerator:  generator})
+	// the forged or mistyped tokens are rejected before HGETALL,
+	// scanners recognize the leaked tokens by multi.OpaqueTokenPrefix.
+
+======== for tokens hashed at rest ==============
+	hasher, err := multi.NewTokenHasher([]byte("your 32 bytes token hashing key.."))
+	if err != nil {
+		panic(err)
+	}
+	hasher.MigrateLegacy = true // move the sessions stored by plain tokens on first use
+	err = multi.InitDriver(&multi.Config{
+		DriverType:      "redis",
+		UniversalClient: redis.NewUniversalClient(options),
+		TokenHasher:     hasher})
+
+======== for claims encrypted at rest ==============
+	keyRing, err := multi.NewKeyRing("2024-06", map[string][]byte{
+		"2024-01": retiredKey, // kept until the sessions sealed by it expire
+		"2024-06": currentKey, // 32 bytes for AES-256
+	})
+	if err != nil {
+		panic(err)
+	}
+	err = multi.InitDriver(&multi.Config{
+		DriverType:      "redis",
+		UniversalClient: redis.NewUniversalClient(options),
+		ClaimsKeyRing:   keyRing})
+	// UpdateUserTokenCacheExpire seals the plaintext or retired sessions by the current key.
+
+======== for session lifecycle events ==============
+	events := multi.NewAsyncDispatcher(&multi.Hooks{
+		OnLogin:         func(e multi.Event) { log.Printf("login %s", e.Claims.Id) },
+		OnRevoke:        func(e multi.Event) { log.Printf("revoke %s", e.Reason) },
+		OnLimitExceeded: func(e multi.Event) { log.Printf("limit exceeded %s", e.Claims.Id) },
+	}, 1024) // or pass the hooks directly to run them synchronously
+	defer events.Close()
+	err := multi.InitDriver(&multi.Config{
+		DriverType:      "redis",
+		UniversalClient: redis.NewUniversalClient(options),
+		Events:          events})
 */
 
 package multi
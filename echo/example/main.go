@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/labstack/echo/v4"
+	"github.com/snowlyg/multi"
+	multi_echo "github.com/snowlyg/multi/echo"
+)
+
+// init 初始化认证驱动
+// 驱动类型： 可选 redis ,local,jwt
+func init() {
+	options := &redis.UniversalOptions{
+		Addrs:       []string{"127.0.0.1:6379"},
+		Password:    "",
+		PoolSize:    10,
+		IdleTimeout: 300 * time.Second,
+	}
+
+	err := multi.InitDriver(&multi.Config{
+		DriverType:      "redis",
+		TokenMaxCount:   10,
+		UniversalClient: redis.NewUniversalClient(options)})
+	if err != nil {
+		panic(fmt.Sprintf("auth is not init get err %v\n", err))
+	}
+}
+
+func auth() echo.MiddlewareFunc {
+	verifier := multi_echo.NewVerifier()
+	verifier.Extractors = []multi_echo.TokenExtractor{multi_echo.FromHeader} // extract token only from Authorization: Bearer $token
+	return verifier.Verify()
+}
+
+func main() {
+	app := echo.New()
+
+	app.GET("/", generateToken)
+
+	protectedAPI := app.Group("/protected")
+	// Register the verify middleware to allow access only to authorized clients.
+	protectedAPI.Use(auth())
+
+	protectedAPI.GET("", protected)
+	// Invalidate the token through server-side, even if it's not expired yet.
+	protectedAPI.GET("/logout", logout)
+
+	// http://localhost:8080
+	// http://localhost:8080/protected (or Authorization: Bearer $token)
+	// http://localhost:8080/protected/logout
+	// http://localhost:8080/protected (401)
+	app.Logger.Fatal(app.Start(":8080"))
+}
+
+func generateToken(ctx echo.Context) error {
+	claims := &multi.MultiClaims{
+		Id:            "1",
+		Username:      "your name",
+		AuthorityId:   "your authority id",
+		TenancyId:     1,
+		TenancyName:   "your tenancy name",
+		AuthorityType: multi.AdminAuthority,
+		LoginType:     multi.LoginTypeWeb,
+		AuthType:      multi.AuthPwd,
+		CreationDate:  time.Now().Local().Unix(),
+		ExpiresAt:     time.Now().Local().Add(multi.RedisSessionTimeoutWeb).Unix(),
+	}
+
+	token, _, err := multi.AuthDriver.GenerateToken(claims)
+	if err != nil {
+		return ctx.NoContent(http.StatusInternalServerError)
+	}
+
+	return ctx.String(http.StatusOK, token)
+}
+
+func protected(ctx echo.Context) error {
+	claims := multi_echo.Get(ctx)
+	return ctx.String(http.StatusOK, fmt.Sprintf("claims=%+v\n", claims))
+}
+
+func logout(ctx echo.Context) error {
+	token := multi_echo.GetVerifiedToken(ctx)
+	if token == nil {
+		return ctx.String(http.StatusOK, "授权凭证为空")
+	}
+	err := multi.AuthDriver.DelUserTokenCache(string(token))
+	if err != nil {
+		return ctx.String(http.StatusOK, err.Error())
+	}
+	return ctx.String(http.StatusOK, "token invalidated, a new token is required to access the protected API")
+}
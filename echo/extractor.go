@@ -0,0 +1,70 @@
+package echo
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/snowlyg/multi"
+)
+
+// TokenExtractor is a function that takes a context as input and returns
+// a token. An empty string should be returned if no token found
+// without additional information.
+type TokenExtractor func(echo.Context) string
+
+// FromHeader is a token extractor.
+// It reads the token from the Authorization request header of form:
+// Authorization: "Bearer {token}".
+func FromHeader(ctx echo.Context) string {
+	return multi.ParseHeaderToken(ctx.Request().Header.Get(echo.HeaderAuthorization), "Bearer")
+}
+
+// FromQuery is a token extractor.
+// It reads the token from the "token" url query parameter.
+func FromQuery(ctx echo.Context) string {
+	return ctx.QueryParam("token")
+}
+
+// FromJSON is a token extractor.
+// Reads a json request body and extracts the json based on the given field.
+// The request content-type should contain the: application/json header value, otherwise
+// this method will not try to read the body.
+// The body is restored after reading, so the handler can bind it again.
+func FromJSON(jsonKey string) TokenExtractor {
+	return func(ctx echo.Context) string {
+		req := ctx.Request()
+		if req.Body == nil || !strings.HasPrefix(req.Header.Get(echo.HeaderContentType), echo.MIMEApplicationJSON) {
+			return ""
+		}
+
+		body, err := io.ReadAll(req.Body)
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			return ""
+		}
+
+		var m echo.Map
+		if err := json.Unmarshal(body, &m); err != nil {
+			return ""
+		}
+
+		if m == nil {
+			return ""
+		}
+
+		v, ok := m[jsonKey]
+		if !ok {
+			return ""
+		}
+
+		tok, ok := v.(string)
+		if !ok {
+			return ""
+		}
+
+		return tok
+	}
+}
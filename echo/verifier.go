@@ -0,0 +1,202 @@
+package echo
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/snowlyg/multi"
+)
+
+const (
+	claimsContextKey        = "echo.multi.claims"
+	verifiedTokenContextKey = "echo.multi.token"
+)
+
+// Get returns the standard claims decoded by a verifier.
+func Get(ctx echo.Context) *multi.MultiClaims {
+	tok, ok := ctx.Get(claimsContextKey).(multi.Claims)
+	if !ok {
+		return nil
+	}
+	return tok.Standard()
+}
+
+// GetClaims returns the custom claims decoded by a VerifierOf[T].
+func GetClaims[T multi.Claims](ctx echo.Context) T {
+	var empty T
+	tok, ok := ctx.Get(claimsContextKey).(T)
+	if !ok {
+		return empty
+	}
+	return tok
+}
+
+// GetAuthorityType 角色类型
+func GetAuthorityType(ctx echo.Context) int {
+	if v := Get(ctx); v != nil {
+		return v.AuthorityType
+	}
+	return 0
+}
+
+// GetAuthorityId 角色id
+func GetAuthorityId(ctx echo.Context) []string {
+	return Get(ctx).AuthorityIds()
+}
+
+// GetUserId 用户id
+func GetUserId(ctx echo.Context) uint {
+	return Get(ctx).UserId()
+}
+
+// GetUsername 用户名
+func GetUsername(ctx echo.Context) string {
+	if v := Get(ctx); v != nil {
+		return v.Username
+	}
+	return ""
+}
+
+// GetTenancyId 商户id
+func GetTenancyId(ctx echo.Context) uint {
+	if v := Get(ctx); v != nil {
+		return v.TenancyId
+	}
+	return 0
+}
+
+// GetTenancyName 商户名称
+func GetTenancyName(ctx echo.Context) string {
+	if v := Get(ctx); v != nil {
+		return v.TenancyName
+	}
+	return ""
+}
+
+// GetCreationDate 登录时间
+func GetCreationDate(ctx echo.Context) int64 {
+	if v := Get(ctx); v != nil {
+		return v.CreationDate
+	}
+	return 0
+}
+
+// GetExpiresIn 有效期
+func GetExpiresIn(ctx echo.Context) int64 {
+	if v := Get(ctx); v != nil {
+		return v.ExpiresAt
+	}
+	return 0
+}
+
+func GetVerifiedToken(ctx echo.Context) []byte {
+	if tok, ok := ctx.Get(verifiedTokenContextKey).([]byte); ok {
+		return tok
+	}
+	return nil
+}
+
+func IsRole(ctx echo.Context, authorityType int) bool {
+	if v := Get(ctx); v != nil {
+		return v.AuthorityType == authorityType
+	}
+	return false
+}
+
+func IsAdmin(ctx echo.Context) bool {
+	return IsRole(ctx, multi.AdminAuthority)
+}
+
+type Verifier = VerifierOf[*multi.MultiClaims]
+
+// VerifierOf verifies the token and stores the claims of type T.
+// The global multi.AuthDriver is used when Driver is nil.
+type VerifierOf[T multi.Claims] struct {
+	Driver       multi.AuthenticationOf[T]
+	Extractors   []TokenExtractor
+	Validators   []multi.TokenValidator
+	ErrorHandler func(ctx echo.Context, err error) error
+}
+
+// DefaultErrorHandler writes the verification error as the problem+json response,
+// the WWW-Authenticate header is set for 401 responses.
+func DefaultErrorHandler(ctx echo.Context, err error) error {
+	vErr := multi.AsVerifyError(err)
+	if challenge := vErr.WWWAuthenticate(); challenge != "" {
+		ctx.Response().Header().Set("WWW-Authenticate", challenge)
+	}
+	body := vErr.ProblemJSON()
+	return ctx.Blob(vErr.Status, multi.ProblemContentType, body)
+}
+
+func NewVerifier(validators ...multi.TokenValidator) *Verifier {
+	return NewVerifierOf[*multi.MultiClaims](nil, validators...)
+}
+
+func NewVerifierOf[T multi.Claims](driver multi.AuthenticationOf[T], validators ...multi.TokenValidator) *VerifierOf[T] {
+	return &VerifierOf[T]{
+		Driver:       driver,
+		Extractors:   []TokenExtractor{FromHeader, FromQuery},
+		ErrorHandler: DefaultErrorHandler,
+		Validators:   validators,
+	}
+}
+
+// Invalidate
+func (v *VerifierOf[T]) invalidate(ctx echo.Context) {
+	if verifiedToken := GetVerifiedToken(ctx); verifiedToken != nil {
+		ctx.Set(claimsContextKey, nil)
+		ctx.Set(verifiedTokenContextKey, nil)
+	}
+}
+
+// RequestToken extracts the token from the
+func (v *VerifierOf[T]) RequestToken(ctx echo.Context) (token string) {
+	for _, extract := range v.Extractors {
+		if token = extract(ctx); token != "" {
+			break // ok we found it.
+		}
+	}
+	return
+}
+
+// VerifyToken verifies the token and returns its claims,
+// the error is a *multi.VerifyError which wraps the cause.
+// The multi.RequestValidators get an empty request, Verify passes the request to them.
+func (v *VerifierOf[T]) VerifyToken(token []byte, validators ...multi.TokenValidator) ([]byte, T, error) {
+	return v.verifyRequest(token, multi.RequestInfo{}, validators...)
+}
+
+// verifyRequest verifies the token by multi.VerifyRequest,
+// the validators of the verifier run before the given ones.
+func (v *VerifierOf[T]) verifyRequest(token []byte, req multi.RequestInfo, validators ...multi.TokenValidator) ([]byte, T, error) {
+	validators = append(append([]multi.TokenValidator{}, v.Validators...), validators...)
+	rcc, err := multi.VerifyRequest(v.Driver, token, req, validators...)
+	if err != nil {
+		return nil, rcc, err
+	}
+	return token, rcc, nil
+}
+
+func (v *VerifierOf[T]) Verify(validators ...multi.TokenValidator) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			token := []byte(v.RequestToken(ctx))
+			verifiedToken, rcc, err := v.verifyRequest(token, requestInfo(ctx), validators...)
+			if err != nil {
+				v.invalidate(ctx)
+				return v.ErrorHandler(ctx, err)
+			}
+			ctx.Set(claimsContextKey, rcc)
+			ctx.Set(verifiedTokenContextKey, verifiedToken)
+			return next(ctx)
+		}
+	}
+}
+
+// requestInfo returns the request metadata for the multi.RequestValidators.
+func requestInfo(ctx echo.Context) multi.RequestInfo {
+	return multi.RequestInfo{
+		Method: ctx.Request().Method,
+		Path:   ctx.Request().URL.Path,
+		IP:     ctx.RealIP(),
+	}
+}
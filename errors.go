@@ -0,0 +1,151 @@
+package multi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ProblemContentType is the content type of the verification error responses, see RFC 7807.
+const ProblemContentType = "application/problem+json"
+
+// verification error codes, they are stable and machine-readable.
+const (
+	ErrCodeMissing   = "token_missing"   // no token in the request
+	ErrCodeMalformed = "token_malformed" // the token or its claims are invalid
+	ErrCodeExpired   = "token_expired"   // the claims are expired
+	ErrCodeRevoked   = "token_revoked"   // the session does not exist any more
+	ErrCodeForbidden = "forbidden"       // the request is not allowed for the claims
+	ErrCodeInternal  = "internal_error"  // the request can not be verified, e.g. no driver
+)
+
+// ErrInternal is matched by the failures of the infrastructure, e.g. the store can not be reached,
+// which are internal errors of the verification, see AsVerifyError.
+var ErrInternal = errors.New("TOKEN CAN NOT BE VERIFIED")
+
+// internalError marks the failure of the infrastructure, it matches ErrInternal and wraps the cause.
+type internalError struct {
+	err error
+}
+
+// internal marks the error as an internal error, nil is returned for a nil error.
+func internal(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &internalError{err: err}
+}
+
+// Error
+func (e *internalError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap
+func (e *internalError) Unwrap() error {
+	return e.err
+}
+
+// Is
+func (e *internalError) Is(target error) bool {
+	return target == ErrInternal
+}
+
+// VerifyError is the typed error returned by the verifiers,
+// it wraps the cause which is still reachable by errors.Is and errors.As.
+type VerifyError struct {
+	Code   string
+	Status int
+	Err    error
+}
+
+// NewVerifyError returns the verification error of the code,
+// the status is derived from the code.
+func NewVerifyError(code string, err error) *VerifyError {
+	status := http.StatusUnauthorized
+	switch code {
+	case ErrCodeForbidden:
+		status = http.StatusForbidden
+	case ErrCodeInternal:
+		status = http.StatusInternalServerError
+	}
+	return &VerifyError{Code: code, Status: status, Err: err}
+}
+
+// Error
+func (e *VerifyError) Error() string {
+	if e.Err == nil {
+		return e.Code
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Err.Error())
+}
+
+// Unwrap
+func (e *VerifyError) Unwrap() error {
+	return e.Err
+}
+
+// AsVerifyError classifies the error of the verification,
+// nil is returned for a nil error.
+// The failures of the infrastructure which match ErrInternal, e.g. ErrDriverNil and the store failures,
+// are internal errors, the other errors are invalid tokens.
+// The custom validators return a *VerifyError to choose another code.
+func AsVerifyError(err error) *VerifyError {
+	if err == nil {
+		return nil
+	}
+	var vErr *VerifyError
+	if errors.As(err, &vErr) {
+		return vErr
+	}
+	switch {
+	case errors.Is(err, ErrEmptyToken):
+		return NewVerifyError(ErrCodeMissing, err)
+	case errors.Is(err, ErrTokenInvalid), errors.Is(err, ErrTokenBlocked):
+		return NewVerifyError(ErrCodeRevoked, err)
+	case errors.Is(err, ErrCsrfInvalid), errors.Is(err, ErrClaimsUnexpected):
+		return NewVerifyError(ErrCodeForbidden, err)
+	case errors.Is(err, ErrInternal):
+		return NewVerifyError(ErrCodeInternal, err)
+	case errors.Is(err, ErrClaimsExpired):
+		return NewVerifyError(ErrCodeExpired, err)
+	default:
+		// ErrTokenMalformed and the jwt.ValidationError, which the claims ValidationError is too.
+		return NewVerifyError(ErrCodeMalformed, err)
+	}
+}
+
+// Problem is the RFC 7807 body of the verification error responses.
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	Code   string `json:"code"`
+}
+
+// Problem returns the problem details of the error.
+func (e *VerifyError) Problem() Problem {
+	p := Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(e.Status),
+		Status: e.Status,
+		Code:   e.Code,
+	}
+	if e.Err != nil && e.Status != http.StatusInternalServerError {
+		p.Detail = e.Err.Error()
+	}
+	return p
+}
+
+// WWWAuthenticate returns the WWW-Authenticate header value of the error, see RFC 6750,
+// an empty string is returned unless the status is 401.
+func (e *VerifyError) WWWAuthenticate() string {
+	if e.Status != http.StatusUnauthorized {
+		return ""
+	}
+	if e.Code == ErrCodeMissing {
+		return "Bearer"
+	}
+	return fmt.Sprintf("Bearer error=\"invalid_token\", error_description=%q", e.Code)
+}
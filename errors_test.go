@@ -0,0 +1,112 @@
+package multi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func TestAsVerifyError(t *testing.T) {
+	expired := newTestClaims(1, func(c *MultiClaims) {
+		c.ExpiresAt = time.Now().Add(-time.Hour).Unix()
+	})
+	tests := []struct {
+		name   string
+		err    error
+		code   string
+		status int
+	}{
+		{name: "missing", err: ErrEmptyToken, code: ErrCodeMissing, status: http.StatusUnauthorized},
+		{name: "revoked", err: fmt.Errorf("get claims %w", ErrTokenInvalid), code: ErrCodeRevoked, status: http.StatusUnauthorized},
+		{name: "expired", err: expired.Valid(), code: ErrCodeExpired, status: http.StatusUnauthorized},
+		{name: "malformed", err: fmt.Errorf("check token %w", ErrTokenMalformed), code: ErrCodeMalformed, status: http.StatusUnauthorized},
+		{name: "missing id", err: (&MultiClaims{ExpiresAt: time.Now().Add(time.Hour).Unix()}).Valid(), code: ErrCodeMalformed, status: http.StatusUnauthorized},
+		{name: "unknown", err: errors.New("signature is invalid"), code: ErrCodeMalformed, status: http.StatusUnauthorized},
+		{name: "store failure", err: internal(errors.New("dial tcp: connection refused")), code: ErrCodeInternal, status: http.StatusInternalServerError},
+		{name: "forbidden", err: ErrCsrfInvalid, code: ErrCodeForbidden, status: http.StatusForbidden},
+		{name: "internal", err: ErrDriverNil, code: ErrCodeInternal, status: http.StatusInternalServerError},
+		{name: "undecryptable", err: fmt.Errorf("get claims %w", ErrClaimsUndecryptable), code: ErrCodeInternal, status: http.StatusInternalServerError},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			vErr := AsVerifyError(test.err)
+			if vErr.Code != test.code {
+				t.Errorf("code want %s but get %s", test.code, vErr.Code)
+			}
+			if vErr.Status != test.status {
+				t.Errorf("status want %d but get %d", test.status, vErr.Status)
+			}
+			if !errors.Is(vErr, test.err) {
+				t.Errorf("verify error should wrap %v", test.err)
+			}
+			if AsVerifyError(vErr) != vErr {
+				t.Errorf("verify error should not be wrapped twice")
+			}
+		})
+	}
+	if AsVerifyError(nil) != nil {
+		t.Error("verify error of nil want nil")
+	}
+}
+
+func TestVerifyErrorProblem(t *testing.T) {
+	vErr := AsVerifyError(ErrTokenInvalid)
+	p := vErr.Problem()
+	if p.Status != http.StatusUnauthorized || p.Code != ErrCodeRevoked || p.Detail != ErrTokenInvalid.Error() {
+		t.Errorf("problem get %+v", p)
+	}
+	want := `Bearer error="invalid_token", error_description="token_revoked"`
+	if got := vErr.WWWAuthenticate(); got != want {
+		t.Errorf("www-authenticate want %s but get %s", want, got)
+	}
+	if got := AsVerifyError(ErrEmptyToken).WWWAuthenticate(); got != "Bearer" {
+		t.Errorf("www-authenticate want Bearer but get %s", got)
+	}
+	if got := AsVerifyError(ErrCsrfInvalid).WWWAuthenticate(); got != "" {
+		t.Errorf("www-authenticate of forbidden want empty but get %s", got)
+	}
+	if detail := AsVerifyError(ErrDriverNil).Problem().Detail; detail != "" {
+		t.Errorf("problem detail of internal error want empty but get %s", detail)
+	}
+}
+
+func TestAsVerifyErrorOfDrivers(t *testing.T) {
+	jwtAuth := NewJwtAuth(nil)
+	_, jwtErr := jwtAuth.GetMultiClaims("garbage")
+	_, localErr := NewLocalAuth().GetMultiClaims("unknown-session-token")
+	down, err := NewRedisAuth(redis.NewUniversalClient(options))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	// the redis server can not be reached by the closed client.
+	down.Client.Close()
+	_, redisErr := down.GetMultiClaims("unknown-session-token")
+
+	tests := []struct {
+		name string
+		err  error
+		code string
+	}{
+		{name: "jwt garbage", err: jwtErr, code: ErrCodeMalformed},
+		{name: "local unknown session", err: localErr, code: ErrCodeRevoked},
+		{name: "redis failure", err: redisErr, code: ErrCodeInternal},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.err == nil {
+				t.Fatal("driver error want not nil")
+			}
+			vErr := AsVerifyError(test.err)
+			if vErr.Code != test.code {
+				t.Errorf("code of %v want %s but get %s", test.err, test.code, vErr.Code)
+			}
+			if vErr.Code == ErrCodeInternal && vErr.Problem().Detail != "" {
+				t.Errorf("problem detail of internal error want empty but get %s", vErr.Problem().Detail)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package multi
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EventType is the type of the session lifecycle events.
+type EventType int
+
+const (
+	EventLogin         EventType = iota + 1 // a session is created by GenerateToken
+	EventLogout                             // a session is deleted by DelUserTokenCache
+	EventRevoke                             // a session is cleaned or expired, see Event.Reason
+	EventRenew                              // a session is renewed by UpdateUserTokenCacheExpire
+	EventLimitExceeded                      // GenerateToken is rejected for exceeding TokenMaxCount
+	EventVerifyFailed                       // GetMultiClaims or VerifyRequest rejects a token
+)
+
+// revoke reasons of the EventRevoke events.
+const (
+	RevokeReasonCleaned = "cleaned" // by CleanUserTokenCache, CleanTenancyTokenCache and so on
+	RevokeReasonExpired = "expired" // the expired session is found and removed by the driver
+)
+
+// String
+func (t EventType) String() string {
+	switch t {
+	case EventLogin:
+		return "login"
+	case EventLogout:
+		return "logout"
+	case EventRevoke:
+		return "revoke"
+	case EventRenew:
+		return "renew"
+	case EventLimitExceeded:
+		return "limit_exceeded"
+	case EventVerifyFailed:
+		return "verify_failed"
+	default:
+		return "unknown"
+	}
+}
+
+// Event is a session lifecycle event dispatched by the drivers.
+// Token is the session id of the redis and local drivers, i.e. the token hashed by the Hasher
+// of the driver if it has one, so the handlers never see the raw token when the tokens are hashed.
+// The jwt driver keeps no session, its events carry the sha256 hex of the token.
+// Claims is nil if the claims are unknown, e.g. when the verification failed.
+// Request is the request of the EventVerifyFailed events dispatched by VerifyRequest.
+type Event struct {
+	Type    EventType
+	Token   string
+	Claims  *MultiClaims
+	Reason  string
+	Err     error
+	Request RequestInfo
+	Time    time.Time
+}
+
+// EventDispatcher receives the session lifecycle events of the drivers,
+// Dispatch is called by the goroutine of the driver call and should not block.
+type EventDispatcher interface {
+	Dispatch(e Event)
+}
+
+// EventHandler handles an event.
+type EventHandler func(e Event)
+
+// Hooks is an EventDispatcher which calls the handler of the event type synchronously,
+// the nil handlers are skipped.
+type Hooks struct {
+	OnLogin         EventHandler
+	OnLogout        EventHandler
+	OnRevoke        EventHandler
+	OnRenew         EventHandler
+	OnLimitExceeded EventHandler
+	OnVerifyFailed  EventHandler
+}
+
+// Dispatch
+func (h *Hooks) Dispatch(e Event) {
+	var handler EventHandler
+	switch e.Type {
+	case EventLogin:
+		handler = h.OnLogin
+	case EventLogout:
+		handler = h.OnLogout
+	case EventRevoke:
+		handler = h.OnRevoke
+	case EventRenew:
+		handler = h.OnRenew
+	case EventLimitExceeded:
+		handler = h.OnLimitExceeded
+	case EventVerifyFailed:
+		handler = h.OnVerifyFailed
+	}
+	if handler != nil {
+		handler(e)
+	}
+}
+
+// AsyncDispatcher is an EventDispatcher which passes the events to the next dispatcher
+// in its own goroutine through a buffer, the events are dropped when the buffer is full,
+// so the slow handlers never delay the drivers.
+type AsyncDispatcher struct {
+	next    EventDispatcher
+	events  chan Event
+	dropped uint64
+	mu      sync.RWMutex
+	closed  bool
+	done    chan struct{}
+}
+
+// NewAsyncDispatcher starts the dispatcher of the buffer size, Close stops it.
+func NewAsyncDispatcher(next EventDispatcher, size int) *AsyncDispatcher {
+	d := &AsyncDispatcher{
+		next:   next,
+		events: make(chan Event, size),
+		done:   make(chan struct{}),
+	}
+	go d.run()
+	return d
+}
+
+// run
+func (d *AsyncDispatcher) run() {
+	defer close(d.done)
+	for e := range d.events {
+		d.next.Dispatch(e)
+	}
+}
+
+// Dispatch queues the event, or drops it if the buffer is full or the dispatcher is closed.
+func (d *AsyncDispatcher) Dispatch(e Event) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		atomic.AddUint64(&d.dropped, 1)
+		return
+	}
+	select {
+	case d.events <- e:
+	default:
+		atomic.AddUint64(&d.dropped, 1)
+	}
+}
+
+// Dropped returns the count of the events dropped as the buffer was full or the dispatcher was closed.
+func (d *AsyncDispatcher) Dropped() uint64 {
+	return atomic.LoadUint64(&d.dropped)
+}
+
+// Close stops the dispatcher after the queued events are dispatched.
+func (d *AsyncDispatcher) Close() {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.events)
+	}
+	d.mu.Unlock()
+	<-d.done
+}
+
+// dispatch sends the event to the dispatcher at the time of the clock, if there is a dispatcher.
+func dispatch(d EventDispatcher, c Clock, e Event) {
+	if d == nil {
+		return
+	}
+	e.Time = clockOf(c).Now()
+	d.Dispatch(e)
+}
+
+// standardOf returns the standard claims, or nil if they are not found.
+func standardOf[T Claims](claims T, err error) *MultiClaims {
+	if err != nil {
+		return nil
+	}
+	return claims.Standard()
+}
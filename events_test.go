@@ -0,0 +1,300 @@
+package multi
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// eventRecorder records the events it receives.
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+// Dispatch
+func (r *eventRecorder) Dispatch(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+}
+
+// types returns the types of the recorded events and resets them.
+func (r *eventRecorder) types() []EventType {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	types := make([]EventType, 0, len(r.events))
+	for _, e := range r.events {
+		types = append(types, e.Type)
+	}
+	r.events = nil
+	return types
+}
+
+func checkEventTypes(t *testing.T, name string, got []EventType, want ...EventType) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("%s events want %v but get %v", name, want, got)
+		return
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("%s events want %v but get %v", name, want, got)
+			return
+		}
+	}
+}
+
+func TestHooks(t *testing.T) {
+	var got []string
+	hooks := &Hooks{
+		OnLogin:  func(e Event) { got = append(got, "login "+e.Token) },
+		OnRevoke: func(e Event) { got = append(got, "revoke "+e.Reason) },
+	}
+	hooks.Dispatch(Event{Type: EventLogin, Token: "t"})
+	hooks.Dispatch(Event{Type: EventLogout, Token: "t"})
+	hooks.Dispatch(Event{Type: EventRevoke, Reason: RevokeReasonExpired})
+	if len(got) != 2 || got[0] != "login t" || got[1] != "revoke expired" {
+		t.Errorf("hooks want [login t revoke expired] but get %v", got)
+	}
+	if EventLimitExceeded.String() != "limit_exceeded" {
+		t.Errorf("event type string want limit_exceeded but get %s", EventLimitExceeded)
+	}
+}
+
+func TestAsyncDispatcher(t *testing.T) {
+	started, block := make(chan struct{}, 3), make(chan struct{})
+	recorder := &eventRecorder{}
+	d := NewAsyncDispatcher(&Hooks{OnLogin: func(e Event) {
+		started <- struct{}{}
+		<-block
+		recorder.Dispatch(e)
+	}}, 2)
+	// the first event is taken by the goroutine, the next two fill the buffer.
+	d.Dispatch(Event{Type: EventLogin, Token: "1"})
+	<-started
+	d.Dispatch(Event{Type: EventLogin, Token: "2"})
+	d.Dispatch(Event{Type: EventLogin, Token: "3"})
+	d.Dispatch(Event{Type: EventLogin, Token: "4"})
+	if d.Dropped() != 1 {
+		t.Errorf("dropped want 1 but get %d", d.Dropped())
+	}
+	close(block)
+	d.Close()
+	if len(recorder.events) != 3 || recorder.events[2].Token != "3" {
+		t.Errorf("events want 1 2 3 but get %v", recorder.events)
+	}
+	d.Close()
+	// the events of the drivers still in use are dropped after Close.
+	d.Dispatch(Event{Type: EventLogin, Token: "5"})
+	if d.Dropped() != 2 {
+		t.Errorf("dropped after close want 2 but get %d", d.Dropped())
+	}
+}
+
+func TestAsyncDispatcherCloseConcurrently(t *testing.T) {
+	d := NewAsyncDispatcher(&Hooks{}, 1)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				d.Dispatch(Event{Type: EventLogin})
+			}
+		}()
+	}
+	d.Close()
+	wg.Wait()
+}
+
+func TestLocalEvents(t *testing.T) {
+	recorder := &eventRecorder{}
+	clock := NewFakeClock(time.Now())
+	auth := NewLocalAuth()
+	auth.Events = recorder
+	auth.Clock = clock
+	claims := newTestClaims(9001, withTenancy(7))
+
+	token, _, err := auth.GenerateToken(claims)
+	if err != nil {
+		t.Fatalf("generate token %v", err)
+	}
+	if err := auth.UpdateUserTokenCacheExpire(token); err != nil {
+		t.Fatalf("update user token cache expire %v", err)
+	}
+	if err := auth.DelUserTokenCache(token); err != nil {
+		t.Fatalf("del user token cache %v", err)
+	}
+	if _, err := auth.GetMultiClaims(token); err == nil {
+		t.Error("get custom claims after del want error")
+	}
+	recorder.mu.Lock()
+	login, verifyFailed := recorder.events[0], recorder.events[3]
+	recorder.mu.Unlock()
+	if login.Token != token || login.Claims.Id != claims.Id || !login.Time.Equal(clock.Now()) {
+		t.Errorf("login event want token, claims and clock time but get %+v", login)
+	}
+	if !errors.Is(verifyFailed.Err, ErrTokenInvalid) {
+		t.Errorf("verify failed event err want %v but get %v", ErrTokenInvalid, verifyFailed.Err)
+	}
+	checkEventTypes(t, "login", recorder.types(), EventLogin, EventRenew, EventLogout, EventVerifyFailed)
+
+	token, _, _ = auth.GenerateToken(claims)
+	clock.Advance(RedisSessionTimeoutWeb + time.Second)
+	auth.GetMultiClaims(token)
+	checkEventTypes(t, "expire", recorder.types(), EventLogin, EventRevoke, EventVerifyFailed)
+
+	auth.GenerateToken(claims)
+	auth.CleanTenancyTokenCache(claims.TenancyId)
+	checkEventTypes(t, "clean", recorder.types(), EventLogin, EventRevoke)
+
+	auth.SetUserTokenMaxCount(0)
+	defer auth.SetUserTokenMaxCount(GtSessionUserMaxTokenDefault)
+	if _, _, err := auth.GenerateToken(claims); err == nil {
+		t.Error("generate token over limit want error")
+	}
+	checkEventTypes(t, "limit", recorder.types(), EventLimitExceeded)
+}
+
+func TestEventsHashedToken(t *testing.T) {
+	h, _ := NewTokenHasher(hasherKey)
+	localAuth := NewLocalAuth()
+	redisAuth, err := NewRedisAuth(redis.NewUniversalClient(options))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	localAuth.Hasher, redisAuth.Hasher = h, h
+	drivers := map[string]struct {
+		auth   Authentication
+		events *EventDispatcher
+	}{
+		"local": {auth: localAuth, events: &localAuth.Events},
+		"redis": {auth: redisAuth, events: &redisAuth.Events},
+	}
+	for name, driver := range drivers {
+		t.Run(name, func(t *testing.T) {
+			recorder := &eventRecorder{}
+			*driver.events = recorder
+			claims := newTestClaims(9004, withTenancy(7))
+			defer driver.auth.CleanUserTokenCache(claims.AuthorityType, claims.Id)
+			token, _, err := driver.auth.GenerateToken(claims)
+			if err != nil {
+				t.Fatalf("generate token %v", err)
+			}
+			driver.auth.UpdateUserTokenCacheExpire(token)
+			driver.auth.DelUserTokenCache(token)
+			driver.auth.GetMultiClaims(token)
+			recorder.mu.Lock()
+			events := recorder.events
+			recorder.mu.Unlock()
+			for _, e := range events {
+				if e.Token != h.Hash(token) {
+					t.Errorf("%s event token want the hash of the token but get %s", e.Type, e.Token)
+				}
+			}
+			checkEventTypes(t, name, recorder.types(), EventLogin, EventRenew, EventLogout, EventVerifyFailed)
+		})
+	}
+}
+
+func TestRedisEvents(t *testing.T) {
+	recorder := &eventRecorder{}
+	redisAuth, err := NewRedisAuth(redis.NewUniversalClient(options))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	clock := NewFakeClock(time.Now().Add(time.Hour))
+	redisAuth.Events = recorder
+	redisAuth.Clock = clock
+	claims := newTestClaims(9002, withTenancy(7))
+	defer redisAuth.CleanUserTokenCache(claims.AuthorityType, claims.Id)
+
+	token, _, err := redisAuth.GenerateToken(claims)
+	if err != nil {
+		t.Fatalf("generate token %v", err)
+	}
+	recorder.mu.Lock()
+	if login := recorder.events[0]; !login.Time.Equal(clock.Now()) {
+		t.Errorf("login event time want the clock time %v but get %v", clock.Now(), login.Time)
+	}
+	recorder.mu.Unlock()
+	redisAuth.UpdateUserTokenCacheExpire(token)
+	redisAuth.DelUserTokenCache(token)
+	redisAuth.GetMultiClaims(token)
+	checkEventTypes(t, "login", recorder.types(), EventLogin, EventRenew, EventLogout, EventVerifyFailed)
+
+	redisAuth.GenerateToken(claims)
+	redisAuth.CleanUserTokenCache(claims.AuthorityType, claims.Id)
+	events := recorder.events
+	checkEventTypes(t, "clean", recorder.types(), EventLogin, EventRevoke)
+	if len(events) == 2 && (events[1].Claims == nil || events[1].Reason != RevokeReasonCleaned) {
+		t.Errorf("clean event want claims and reason but get %+v", events[1])
+	}
+}
+
+func TestJwtEvents(t *testing.T) {
+	recorder := &eventRecorder{}
+	auth := NewJwtAuth(nil)
+	auth.Events = recorder
+	token, _, err := auth.GenerateToken(newTestClaims(9003, withTenancy(7)))
+	if err != nil {
+		t.Fatalf("generate token %v", err)
+	}
+	auth.GetMultiClaims(token + "x")
+	auth.CleanAllUserTokenCache("9003")
+	recorder.mu.Lock()
+	for _, e := range recorder.events[:2] {
+		if e.Token == "" || strings.Contains(token+"x", e.Token) {
+			t.Errorf("%s event token want the hash of the token but get %s", e.Type, e.Token)
+		}
+	}
+	recorder.mu.Unlock()
+	checkEventTypes(t, "jwt", recorder.types(), EventLogin, EventVerifyFailed, EventRevoke)
+}
+
+func TestVerifyRequestEvents(t *testing.T) {
+	recorder := &eventRecorder{}
+	auth := NewLocalAuth()
+	auth.Events = recorder
+	token, _, err := auth.GenerateToken(newTestClaims(9005))
+	if err != nil {
+		t.Fatalf("generate token %v", err)
+	}
+	defer auth.DelUserTokenCache(token)
+	recorder.types()
+	req := RequestInfo{Method: "GET", Path: "/", IP: "10.0.0.1"}
+
+	tests := []struct {
+		name       string
+		token      string
+		validators []TokenValidator
+		err        error
+	}{
+		{name: "empty token", err: ErrEmptyToken},
+		{name: "unknown session", token: "unknown-session-token", err: ErrTokenInvalid},
+		{name: "rejected by validator", token: token, validators: []TokenValidator{Expected{TenancyId: 9}}, err: ErrClaimsUnexpected},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := VerifyRequest[*MultiClaims](auth, []byte(test.token), req, test.validators...); !errors.Is(err, test.err) {
+				t.Fatalf("verify request want %v but get %v", test.err, err)
+			}
+			recorder.mu.Lock()
+			events := recorder.events
+			recorder.mu.Unlock()
+			checkEventTypes(t, test.name, recorder.types(), EventVerifyFailed)
+			if len(events) == 1 && (events[0].Request != req || !errors.Is(events[0].Err, test.err)) {
+				t.Errorf("verify failed event want the request and error but get %+v", events[0])
+			}
+		})
+	}
+	if _, err := VerifyRequest[*MultiClaims](auth, []byte(token), req); err != nil {
+		t.Fatalf("verify request %v", err)
+	}
+	checkEventTypes(t, "valid token", recorder.types())
+}
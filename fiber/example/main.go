@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/gofiber/fiber/v2"
+	"github.com/snowlyg/multi"
+	multi_fiber "github.com/snowlyg/multi/fiber"
+)
+
+// init 初始化认证驱动
+// 驱动类型： 可选 redis ,local,jwt
+func init() {
+	options := &redis.UniversalOptions{
+		Addrs:       []string{"127.0.0.1:6379"},
+		Password:    "",
+		PoolSize:    10,
+		IdleTimeout: 300 * time.Second,
+	}
+
+	err := multi.InitDriver(&multi.Config{
+		DriverType:      "redis",
+		TokenMaxCount:   10,
+		UniversalClient: redis.NewUniversalClient(options)})
+	if err != nil {
+		panic(fmt.Sprintf("auth is not init get err %v\n", err))
+	}
+}
+
+func auth() fiber.Handler {
+	verifier := multi_fiber.NewVerifier()
+	verifier.Extractors = []multi_fiber.TokenExtractor{multi_fiber.FromHeader} // extract token only from Authorization: Bearer $token
+	return verifier.Verify()
+}
+
+func main() {
+	app := fiber.New()
+
+	app.Get("/", generateToken)
+
+	protectedAPI := app.Group("/protected")
+	// Register the verify middleware to allow access only to authorized clients.
+	protectedAPI.Use(auth())
+
+	protectedAPI.Get("/", protected)
+	// Invalidate the token through server-side, even if it's not expired yet.
+	protectedAPI.Get("/logout", logout)
+
+	// http://localhost:8080
+	// http://localhost:8080/protected (or Authorization: Bearer $token)
+	// http://localhost:8080/protected/logout
+	// http://localhost:8080/protected (401)
+	log.Fatal(app.Listen(":8080"))
+}
+
+func generateToken(ctx *fiber.Ctx) error {
+	claims := &multi.MultiClaims{
+		Id:            "1",
+		Username:      "your name",
+		AuthorityId:   "your authority id",
+		TenancyId:     1,
+		TenancyName:   "your tenancy name",
+		AuthorityType: multi.AdminAuthority,
+		LoginType:     multi.LoginTypeWeb,
+		AuthType:      multi.AuthPwd,
+		CreationDate:  time.Now().Local().Unix(),
+		ExpiresAt:     time.Now().Local().Add(multi.RedisSessionTimeoutWeb).Unix(),
+	}
+
+	token, _, err := multi.AuthDriver.GenerateToken(claims)
+	if err != nil {
+		return ctx.SendStatus(fiber.StatusInternalServerError)
+	}
+
+	return ctx.SendString(token)
+}
+
+func protected(ctx *fiber.Ctx) error {
+	claims := multi_fiber.Get(ctx)
+	return ctx.SendString(fmt.Sprintf("claims=%+v\n", claims))
+}
+
+func logout(ctx *fiber.Ctx) error {
+	token := multi_fiber.GetVerifiedToken(ctx)
+	if token == nil {
+		return ctx.SendString("授权凭证为空")
+	}
+	err := multi.AuthDriver.DelUserTokenCache(string(token))
+	if err != nil {
+		return ctx.SendString(err.Error())
+	}
+	return ctx.SendString("token invalidated, a new token is required to access the protected API")
+}
@@ -0,0 +1,61 @@
+package fiber
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/snowlyg/multi"
+)
+
+// TokenExtractor is a function that takes a context as input and returns
+// a token. An empty string should be returned if no token found
+// without additional information.
+type TokenExtractor func(*fiber.Ctx) string
+
+// FromHeader is a token extractor.
+// It reads the token from the Authorization request header of form:
+// Authorization: "Bearer {token}".
+func FromHeader(ctx *fiber.Ctx) string {
+	return multi.ParseHeaderToken(ctx.Get(fiber.HeaderAuthorization), "Bearer")
+}
+
+// FromQuery is a token extractor.
+// It reads the token from the "token" url query parameter.
+func FromQuery(ctx *fiber.Ctx) string {
+	return ctx.Query("token")
+}
+
+// FromJSON is a token extractor.
+// Reads a json request body and extracts the json based on the given field.
+// The request content-type should contain the: application/json header value, otherwise
+// this method will not try to read the body.
+// The body is kept by fasthttp, so the handler can parse it again.
+func FromJSON(jsonKey string) TokenExtractor {
+	return func(ctx *fiber.Ctx) string {
+		if !strings.HasPrefix(string(ctx.Request().Header.ContentType()), fiber.MIMEApplicationJSON) {
+			return ""
+		}
+
+		var m fiber.Map
+		if err := json.Unmarshal(ctx.Body(), &m); err != nil {
+			return ""
+		}
+
+		if m == nil {
+			return ""
+		}
+
+		v, ok := m[jsonKey]
+		if !ok {
+			return ""
+		}
+
+		tok, ok := v.(string)
+		if !ok {
+			return ""
+		}
+
+		return tok
+	}
+}
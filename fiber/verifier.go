@@ -0,0 +1,201 @@
+package fiber
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/snowlyg/multi"
+)
+
+const (
+	claimsContextKey        = "fiber.multi.claims"
+	verifiedTokenContextKey = "fiber.multi.token"
+)
+
+// Get returns the standard claims decoded by a verifier.
+func Get(ctx *fiber.Ctx) *multi.MultiClaims {
+	tok, ok := ctx.Locals(claimsContextKey).(multi.Claims)
+	if !ok {
+		return nil
+	}
+	return tok.Standard()
+}
+
+// GetClaims returns the custom claims decoded by a VerifierOf[T].
+func GetClaims[T multi.Claims](ctx *fiber.Ctx) T {
+	var empty T
+	tok, ok := ctx.Locals(claimsContextKey).(T)
+	if !ok {
+		return empty
+	}
+	return tok
+}
+
+// GetAuthorityType 角色类型
+func GetAuthorityType(ctx *fiber.Ctx) int {
+	if v := Get(ctx); v != nil {
+		return v.AuthorityType
+	}
+	return 0
+}
+
+// GetAuthorityId 角色id
+func GetAuthorityId(ctx *fiber.Ctx) []string {
+	return Get(ctx).AuthorityIds()
+}
+
+// GetUserId 用户id
+func GetUserId(ctx *fiber.Ctx) uint {
+	return Get(ctx).UserId()
+}
+
+// GetUsername 用户名
+func GetUsername(ctx *fiber.Ctx) string {
+	if v := Get(ctx); v != nil {
+		return v.Username
+	}
+	return ""
+}
+
+// GetTenancyId 商户id
+func GetTenancyId(ctx *fiber.Ctx) uint {
+	if v := Get(ctx); v != nil {
+		return v.TenancyId
+	}
+	return 0
+}
+
+// GetTenancyName 商户名称
+func GetTenancyName(ctx *fiber.Ctx) string {
+	if v := Get(ctx); v != nil {
+		return v.TenancyName
+	}
+	return ""
+}
+
+// GetCreationDate 登录时间
+func GetCreationDate(ctx *fiber.Ctx) int64 {
+	if v := Get(ctx); v != nil {
+		return v.CreationDate
+	}
+	return 0
+}
+
+// GetExpiresIn 有效期
+func GetExpiresIn(ctx *fiber.Ctx) int64 {
+	if v := Get(ctx); v != nil {
+		return v.ExpiresAt
+	}
+	return 0
+}
+
+func GetVerifiedToken(ctx *fiber.Ctx) []byte {
+	if tok, ok := ctx.Locals(verifiedTokenContextKey).([]byte); ok {
+		return tok
+	}
+	return nil
+}
+
+func IsRole(ctx *fiber.Ctx, authorityType int) bool {
+	if v := Get(ctx); v != nil {
+		return v.AuthorityType == authorityType
+	}
+	return false
+}
+
+func IsAdmin(ctx *fiber.Ctx) bool {
+	return IsRole(ctx, multi.AdminAuthority)
+}
+
+type Verifier = VerifierOf[*multi.MultiClaims]
+
+// VerifierOf verifies the token and stores the claims of type T.
+// The global multi.AuthDriver is used when Driver is nil.
+type VerifierOf[T multi.Claims] struct {
+	Driver       multi.AuthenticationOf[T]
+	Extractors   []TokenExtractor
+	Validators   []multi.TokenValidator
+	ErrorHandler func(ctx *fiber.Ctx, err error) error
+}
+
+// DefaultErrorHandler writes the verification error as the problem+json response,
+// the WWW-Authenticate header is set for 401 responses.
+func DefaultErrorHandler(ctx *fiber.Ctx, err error) error {
+	vErr := multi.AsVerifyError(err)
+	if challenge := vErr.WWWAuthenticate(); challenge != "" {
+		ctx.Set(fiber.HeaderWWWAuthenticate, challenge)
+	}
+	body := vErr.ProblemJSON()
+	ctx.Set(fiber.HeaderContentType, multi.ProblemContentType)
+	return ctx.Status(vErr.Status).Send(body)
+}
+
+func NewVerifier(validators ...multi.TokenValidator) *Verifier {
+	return NewVerifierOf[*multi.MultiClaims](nil, validators...)
+}
+
+func NewVerifierOf[T multi.Claims](driver multi.AuthenticationOf[T], validators ...multi.TokenValidator) *VerifierOf[T] {
+	return &VerifierOf[T]{
+		Driver:       driver,
+		Extractors:   []TokenExtractor{FromHeader, FromQuery},
+		ErrorHandler: DefaultErrorHandler,
+		Validators:   validators,
+	}
+}
+
+// Invalidate
+func (v *VerifierOf[T]) invalidate(ctx *fiber.Ctx) {
+	if verifiedToken := GetVerifiedToken(ctx); verifiedToken != nil {
+		ctx.Locals(claimsContextKey, nil)
+		ctx.Locals(verifiedTokenContextKey, nil)
+	}
+}
+
+// RequestToken extracts the token from the
+func (v *VerifierOf[T]) RequestToken(ctx *fiber.Ctx) (token string) {
+	for _, extract := range v.Extractors {
+		if token = extract(ctx); token != "" {
+			break // ok we found it.
+		}
+	}
+	return
+}
+
+// VerifyToken verifies the token and returns its claims,
+// the error is a *multi.VerifyError which wraps the cause.
+// The multi.RequestValidators get an empty request, Verify passes the request to them.
+func (v *VerifierOf[T]) VerifyToken(token []byte, validators ...multi.TokenValidator) ([]byte, T, error) {
+	return v.verifyRequest(token, multi.RequestInfo{}, validators...)
+}
+
+// verifyRequest verifies the token by multi.VerifyRequest,
+// the validators of the verifier run before the given ones.
+func (v *VerifierOf[T]) verifyRequest(token []byte, req multi.RequestInfo, validators ...multi.TokenValidator) ([]byte, T, error) {
+	validators = append(append([]multi.TokenValidator{}, v.Validators...), validators...)
+	rcc, err := multi.VerifyRequest(v.Driver, token, req, validators...)
+	if err != nil {
+		return nil, rcc, err
+	}
+	return token, rcc, nil
+}
+
+func (v *VerifierOf[T]) Verify(validators ...multi.TokenValidator) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		token := []byte(v.RequestToken(ctx))
+		verifiedToken, rcc, err := v.verifyRequest(token, requestInfo(ctx), validators...)
+		if err != nil {
+			v.invalidate(ctx)
+			return v.ErrorHandler(ctx, err)
+		}
+		ctx.Locals(claimsContextKey, rcc)
+		ctx.Locals(verifiedTokenContextKey, verifiedToken)
+		return ctx.Next()
+	}
+}
+
+// requestInfo returns the request metadata for the multi.RequestValidators.
+func requestInfo(ctx *fiber.Ctx) multi.RequestInfo {
+	return multi.RequestInfo{
+		Method: ctx.Method(),
+		Path:   ctx.Path(),
+		IP:     ctx.IP(),
+	}
+}
@@ -0,0 +1,80 @@
+package fiber
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/snowlyg/multi"
+)
+
+// newTestClaims returns the valid claims of the admin user.
+func newTestClaims(id uint) *multi.MultiClaims {
+	return multi.New(&multi.Multi{
+		Id:            id,
+		Username:      "username",
+		AuthorityIds:  []string{"999"},
+		AuthorityType: multi.AdminAuthority,
+		LoginType:     multi.LoginTypeWeb,
+		AuthType:      multi.AuthPwd,
+		ExpiresAt:     time.Now().Add(multi.RedisSessionTimeoutWeb).Unix(),
+	})
+}
+
+func TestVerify(t *testing.T) {
+	driver := multi.NewLocalAuth()
+	token, _, err := driver.GenerateToken(newTestClaims(1))
+	if err != nil {
+		t.Fatalf("generate token %v", err)
+	}
+	defer driver.DelUserTokenCache(token)
+
+	verifier := NewVerifierOf[*multi.MultiClaims](driver)
+	handler := func(ctx *fiber.Ctx) error {
+		return ctx.SendString(strconv.FormatUint(uint64(GetUserId(ctx)), 10))
+	}
+	app := fiber.New()
+	app.Get("/", verifier.Verify(), handler)
+	app.Get("/tenancy", verifier.Verify(multi.Expected{TenancyId: 9}), handler)
+
+	tests := []struct {
+		name      string
+		path      string
+		token     string
+		status    int
+		challenge string
+		body      string
+	}{
+		{name: "missing token", path: "/", status: http.StatusUnauthorized, challenge: "Bearer"},
+		{name: "invalid token", path: "/", token: "invalid", status: http.StatusUnauthorized, challenge: `Bearer error="invalid_token", error_description="token_revoked"`},
+		{name: "valid token", path: "/", token: token, status: http.StatusOK, body: "1"},
+		{name: "rejected by validator", path: "/tenancy", token: token, status: http.StatusForbidden},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, test.path, nil)
+			if test.token != "" {
+				req.Header.Set("Authorization", "Bearer "+test.token)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("test request %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != test.status {
+				t.Fatalf("status want %d but get %d", test.status, resp.StatusCode)
+			}
+			if challenge := resp.Header.Get("WWW-Authenticate"); challenge != test.challenge {
+				t.Errorf("WWW-Authenticate want %q but get %q", test.challenge, challenge)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if test.body != "" && string(body) != test.body {
+				t.Errorf("user id in context want %s but get %s", test.body, body)
+			}
+		})
+	}
+}
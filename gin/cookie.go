@@ -0,0 +1,87 @@
+package gin
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/snowlyg/multi"
+)
+
+// CookieOptions configures the cookie which carries the token or the csrf token,
+// the token cookie is always HttpOnly.
+type CookieOptions struct {
+	Name     string
+	Path     string
+	Domain   string
+	Secure   bool
+	SameSite http.SameSite
+}
+
+// TokenCookie is used by FromCookie, SetTokenCookie and ClearTokenCookie.
+var TokenCookie = CookieOptions{
+	Name:     "multi_token",
+	Path:     "/",
+	Secure:   true,
+	SameSite: http.SameSiteLaxMode,
+}
+
+// FromCookie is a token extractor.
+// It reads the token from the TokenCookie cookie.
+func FromCookie(ctx *gin.Context) string {
+	return FromCookieName(TokenCookie.Name)(ctx)
+}
+
+// FromCookieName is a token extractor.
+// It reads the token from the cookie of the given name.
+func FromCookieName(name string) TokenExtractor {
+	return func(ctx *gin.Context) string {
+		if token, err := ctx.Cookie(name); err == nil {
+			return token
+		}
+		return ""
+	}
+}
+
+// IssueTokenCookie generates the token by the driver and sets it as the TokenCookie cookie,
+// the csrf token of the new session is issued as well, see IssueCsrfToken.
+// The cookies are only set when both are issued, see multi.IssueToken.
+func IssueTokenCookie[T multi.Claims](ctx *gin.Context, driver multi.AuthenticationOf[T], claims T) (string, error) {
+	token, secret, err := multi.IssueToken(driver, claims)
+	if err != nil {
+		return "", err
+	}
+	loginType := claims.Standard().LoginType
+	SetTokenCookie(ctx, token, loginType)
+	setCsrfToken(ctx, secret, loginType)
+	return token, nil
+}
+
+// SetTokenCookie sets the token as the TokenCookie cookie,
+// the cookie lives as long as the session of the login type.
+func SetTokenCookie(ctx *gin.Context, token string, loginType int) {
+	expire := multi.GetTokenExpire(loginType)
+	setCookie(ctx, TokenCookie, token, int(expire.Seconds()), time.Now().Add(expire), true)
+}
+
+// ClearTokenCookie removes the TokenCookie and CsrfCookie cookies, it should be called on logout.
+func ClearTokenCookie(ctx *gin.Context) {
+	setCookie(ctx, TokenCookie, "", -1, time.Unix(0, 0), true)
+	setCookie(ctx, CsrfCookie, "", -1, time.Unix(0, 0), false)
+}
+
+// setCookie
+func setCookie(ctx *gin.Context, opts CookieOptions, value string, maxAge int, expires time.Time, httpOnly bool) {
+	cookie := &http.Cookie{
+		Name:     opts.Name,
+		Value:    value,
+		Path:     opts.Path,
+		Domain:   opts.Domain,
+		MaxAge:   maxAge,
+		Expires:  expires,
+		Secure:   opts.Secure,
+		HttpOnly: httpOnly,
+		SameSite: opts.SameSite,
+	}
+	http.SetCookie(ctx.Writer, cookie)
+}
@@ -0,0 +1,66 @@
+package gin
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/snowlyg/multi"
+)
+
+// csrfFailingDriver records the generated token and fails to bind the csrf secret.
+type csrfFailingDriver struct {
+	multi.Authentication
+	token string
+}
+
+func (d *csrfFailingDriver) GenerateToken(claims *multi.MultiClaims) (string, int64, error) {
+	token, expiresAt, err := d.Authentication.GenerateToken(claims)
+	d.token = token
+	return token, expiresAt, err
+}
+
+func (d *csrfFailingDriver) SetCsrfSecret(token string) (string, error) {
+	return "", errors.New("set csrf secret failed")
+}
+
+func TestIssueTokenCookie(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	driver := multi.NewLocalAuth()
+
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	token, err := IssueTokenCookie(ctx, multi.Authentication(driver), newTestClaims(2))
+	if err != nil {
+		t.Fatalf("issue token cookie %v", err)
+	}
+	defer driver.DelUserTokenCache(token)
+	cookies := map[string]string{}
+	for _, cookie := range w.Result().Cookies() {
+		cookies[cookie.Name] = cookie.Value
+	}
+	secret, _ := driver.GetCsrfSecret(token)
+	if cookies[TokenCookie.Name] != token || cookies[CsrfCookie.Name] != secret || w.Header().Get(CsrfHeader) != secret {
+		t.Errorf("token and csrf cookies want set but get %v", cookies)
+	}
+
+	w = httptest.NewRecorder()
+	ctx, _ = gin.CreateTestContext(w)
+	failing := &csrfFailingDriver{Authentication: driver}
+	if _, err := IssueTokenCookie(ctx, multi.Authentication(failing), newTestClaims(2)); err == nil {
+		t.Fatal("issue token cookie want error")
+	}
+	if failing.token == "" {
+		t.Fatal("token of the failed login want generated")
+	}
+	if cookies := w.Header().Values("Set-Cookie"); len(cookies) > 0 {
+		t.Errorf("cookies want not set but get %v", cookies)
+	}
+	if _, err := driver.GetMultiClaims(failing.token); err == nil {
+		t.Error("session of the failed login want deleted")
+	}
+	if _, err := IssueTokenCookie(ctx, multi.Authentication(nil), newTestClaims(2)); !errors.Is(err, multi.ErrDriverNil) {
+		t.Errorf("issue token cookie without driver want %v but get %v", multi.ErrDriverNil, err)
+	}
+}
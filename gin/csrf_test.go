@@ -0,0 +1,72 @@
+package gin
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/snowlyg/multi"
+)
+
+func TestCsrf(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	if err := multi.InitDriver(&multi.Config{DriverType: "local"}); err != nil {
+		t.Fatalf("init driver %v", err)
+	}
+	token, _, err := multi.AuthDriver.GenerateToken(newTestClaims(1))
+	if err != nil {
+		t.Fatalf("generate token %v", err)
+	}
+	defer multi.AuthDriver.DelUserTokenCache(token)
+	secret, err := multi.AuthDriver.SetCsrfSecret(token)
+	if err != nil {
+		t.Fatalf("set csrf secret %v", err)
+	}
+
+	router := gin.New()
+	router.Use(NewVerifier().Verify(), Csrf(multi.AuthDriver))
+	router.Any("/", func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name   string
+		method string
+		query  string
+		header string
+		form   string
+		status int
+	}{
+		{name: "safe method", method: http.MethodGet, status: http.StatusOK},
+		{name: "missing token", method: http.MethodPost, status: http.StatusForbidden},
+		{name: "wrong token", method: http.MethodPost, header: "wrong", status: http.StatusForbidden},
+		{name: "token in query", method: http.MethodPost, query: "?" + CsrfFormField + "=" + secret, status: http.StatusForbidden},
+		{name: "valid header", method: http.MethodPost, header: secret, status: http.StatusOK},
+		{name: "valid form", method: http.MethodPost, form: secret, status: http.StatusOK},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var body io.Reader
+			if test.form != "" {
+				body = strings.NewReader(url.Values{CsrfFormField: {test.form}}.Encode())
+			}
+			req := httptest.NewRequest(test.method, "/"+test.query, body)
+			req.Header.Set("Authorization", "Bearer "+token)
+			if test.header != "" {
+				req.Header.Set(CsrfHeader, test.header)
+			}
+			if test.form != "" {
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != test.status {
+				t.Errorf("status want %d but get %d", test.status, w.Code)
+			}
+		})
+	}
+}
@@ -42,14 +42,23 @@ func init() {
 
 func auth() gin.HandlerFunc {
 	verifier := multi_gin.NewVerifier()
-	verifier.Extractors = []multi_gin.TokenExtractor{multi_gin.FromHeader} // extract token only from Authorization: Bearer $token
+	verifier.Extractors = []multi_gin.TokenExtractor{multi_gin.FromHeader, multi_gin.FromCookie} // extract token from Authorization: Bearer $token or the token cookie
 	return verifier.Verify()
 }
 
+func optionalAuth() gin.HandlerFunc {
+	verifier := multi_gin.NewVerifier()
+	verifier.Extractors = []multi_gin.TokenExtractor{multi_gin.FromHeader, multi_gin.FromCookie}
+	return verifier.VerifyOptional()
+}
+
 func main() {
 	app := gin.New()
 
 	app.GET("/", generateToken())
+	app.GET("/cookie", generateTokenCookie)
+	// Allow anonymous clients, the claims are set when the token is valid.
+	app.GET("/public", optionalAuth(), public)
 
 	protectedAPI := app.Group("/protected")
 	// Register the verify middleware to allow access only to authorized clients.
@@ -59,9 +68,20 @@ func main() {
 	protectedAPI.GET("/", protected)
 	// Invalidate the token through server-side, even if it's not expired yet.
 	protectedAPI.GET("/logout", logout)
+	// Allow only the admin, or the tenancy 1 with authority id "999".
+	protectedAPI.GET("/admin", multi_gin.Require(multi_gin.Or(
+		multi_gin.AuthorityType(multi.AdminAuthority),
+		multi_gin.And(multi_gin.Tenancy(1), multi_gin.AnyAuthorityId("999")),
+	)), protected)
+	// Unsafe requests authenticated by the token cookie must send the csrf token in X-CSRF-Token.
+	protectedAPI.POST("/", multi_gin.Csrf(multi.AuthDriver), protected)
 
 	// http://localhost:8080
+	// http://localhost:8080/cookie (or the token cookie)
+	// http://localhost:8080/public (with or without the token)
 	// http://localhost:8080/protected (or Authorization: Bearer $token)
+	// http://localhost:8080/protected/admin
+	// POST http://localhost:8080/protected (X-CSRF-Token: $csrf)
 	// http://localhost:8080/protected/logout
 	// http://localhost:8080/protected (401)
 	app.Run(":8080")
@@ -92,6 +112,37 @@ func generateToken() gin.HandlerFunc {
 	}
 }
 
+func generateTokenCookie(ctx *gin.Context) {
+	claims := &multi.MultiClaims{
+		Id:            "1",
+		Username:      "your name",
+		AuthorityId:   "your authority id",
+		TenancyId:     1,
+		TenancyName:   "your tenancy name",
+		AuthorityType: multi.AdminAuthority,
+		LoginType:     multi.LoginTypeWeb,
+		AuthType:      multi.AuthPwd,
+		CreationDate:  time.Now().Local().Unix(),
+		ExpiresAt:     time.Now().Local().Add(multi.RedisSessionTimeoutWeb).Unix(),
+	}
+
+	// the token is sent as an HttpOnly, Secure, SameSite cookie
+	if _, err := multi_gin.IssueTokenCookie(ctx, multi.AuthDriver, claims); err != nil {
+		ctx.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	ctx.String(http.StatusOK, "token cookie issued")
+}
+
+func public(ctx *gin.Context) {
+	if multi_gin.IsAnonymous(ctx) {
+		ctx.String(http.StatusOK, "hello guest")
+		return
+	}
+	protected(ctx)
+}
+
 func protected(ctx *gin.Context) {
 	claims := multi_gin.Get(ctx)
 	ctx.JSON(http.StatusOK, fmt.Sprintf("claims=%+v\n", claims))
@@ -108,5 +159,6 @@ func logout(ctx *gin.Context) {
 		ctx.JSON(http.StatusOK, err.Error())
 		return
 	}
+	multi_gin.ClearTokenCookie(ctx)
 	ctx.String(http.StatusOK, "token invalidated, a new token is required to access the protected API")
 }
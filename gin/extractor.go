@@ -1,10 +1,14 @@
 package gin
 
 import (
+	"bytes"
+	"encoding/json"
+	"io"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
+	"github.com/snowlyg/multi"
 )
 
 // TokenExtractor is a function that takes a context as input and returns
@@ -16,55 +20,84 @@ type TokenExtractor func(*gin.Context) string
 // It reads the token from the Authorization request header of form:
 // Authorization: "Bearer {token}".
 func FromHeader(ctx *gin.Context) string {
-	authHeader := ctx.GetHeader("Authorization")
-	if authHeader == "" {
-		return ""
-	}
+	return FromHeaderName("Authorization", "Bearer")(ctx)
+}
 
-	// pure check: authorization header format must be Bearer {token}
-	authHeaderParts := strings.Split(authHeader, " ")
-	if len(authHeaderParts) != 2 || strings.ToLower(authHeaderParts[0]) != "bearer" {
-		return ""
+// FromHeaderName is a token extractor.
+// It reads the token from the request header of the given name and form:
+// name: "{scheme} {token}", the scheme is case-insensitive and may be empty
+// to read the whole header value as the token.
+func FromHeaderName(name, scheme string) TokenExtractor {
+	return func(ctx *gin.Context) string {
+		return multi.ParseHeaderToken(ctx.GetHeader(name), scheme)
 	}
+}
 
-	return authHeaderParts[1]
+// FromWebSocketProtocol is a token extractor.
+// It reads the token from the Sec-WebSocket-Protocol request header of form:
+// Sec-WebSocket-Protocol: "bearer, {token}",
+// as browsers can not set the Authorization header on websocket upgrades.
+// The upgrader should respond with the WebSocketProtocol subprotocol.
+func FromWebSocketProtocol(ctx *gin.Context) string {
+	protocols := strings.Split(ctx.GetHeader("Sec-WebSocket-Protocol"), ",")
+	for i := 0; i < len(protocols)-1; i++ {
+		if strings.EqualFold(strings.TrimSpace(protocols[i]), WebSocketProtocol) {
+			return strings.TrimSpace(protocols[i+1])
+		}
+	}
+	return ""
 }
 
 // FromQuery is a token extractor.
 // It reads the token from the "token" url query parameter.
 func FromQuery(ctx *gin.Context) string {
-	return ctx.Query("token")
+	return strings.TrimSpace(ctx.Query("token"))
+}
+
+// FromForm is a token extractor.
+// It reads the token from the given field of the url-encoded or multipart form body,
+// the parsed form stays available to the handler.
+func FromForm(field string) TokenExtractor {
+	return func(ctx *gin.Context) string {
+		return strings.TrimSpace(ctx.PostForm(field))
+	}
+}
+
+// FromParam is a token extractor.
+// It reads the token from the given path parameter, e.g. "/files/:token".
+func FromParam(name string) TokenExtractor {
+	return func(ctx *gin.Context) string {
+		return strings.TrimSpace(ctx.Param(name))
+	}
 }
 
 // FromJSON is a token extractor.
 // Reads a json request body and extracts the json based on the given field.
 // The request content-type should contain the: application/json header value, otherwise
-// this method will not try to read and consume the body.
+// this method will not try to read the body.
+// The body is restored after reading, so the handler can still bind it.
 func FromJSON(jsonKey string) TokenExtractor {
 	return func(ctx *gin.Context) string {
-		if ctx.ContentType() != binding.MIMEJSON {
+		if ctx.ContentType() != binding.MIMEJSON || ctx.Request.Body == nil {
 			return ""
 		}
 
-		var m gin.H
-		if err := ctx.BindJSON(&m); err != nil {
+		body, err := io.ReadAll(ctx.Request.Body)
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+		if err != nil {
 			return ""
 		}
 
-		if m == nil {
-			return ""
-		}
-
-		v, ok := m[jsonKey]
-		if !ok {
+		var m map[string]interface{}
+		if err := json.Unmarshal(body, &m); err != nil {
 			return ""
 		}
 
-		tok, ok := v.(string)
+		tok, ok := m[jsonKey].(string)
 		if !ok {
 			return ""
 		}
 
-		return tok
+		return strings.TrimSpace(tok)
 	}
 }
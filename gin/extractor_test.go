@@ -0,0 +1,95 @@
+package gin
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestExtractors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name        string
+		extractor   TokenExtractor
+		path        string
+		header      map[string]string
+		contentType string
+		body        string
+		token       string
+	}{
+		{name: "header", extractor: FromHeader, header: map[string]string{"Authorization": "Bearer token"}, token: "token"},
+		{name: "lower case scheme", extractor: FromHeader, header: map[string]string{"Authorization": "bearer token"}, token: "token"},
+		{name: "extra spaces", extractor: FromHeader, header: map[string]string{"Authorization": "Bearer  token "}, token: "token"},
+		{name: "other scheme", extractor: FromHeader, header: map[string]string{"Authorization": "Basic token"}},
+		{name: "missing token", extractor: FromHeader, header: map[string]string{"Authorization": "Bearer"}},
+		{name: "custom header", extractor: FromHeaderName("X-Api-Key", "Token"), header: map[string]string{"X-Api-Key": "TOKEN token"}, token: "token"},
+		{name: "custom header without scheme", extractor: FromHeaderName("X-Api-Key", ""), header: map[string]string{"X-Api-Key": " token "}, token: "token"},
+		{name: "custom header other scheme", extractor: FromHeaderName("X-Api-Key", "Token"), header: map[string]string{"X-Api-Key": "Bearer token"}},
+		{name: "query", extractor: FromQuery, path: "/?token=token", token: "token"},
+		{name: "form", extractor: FromForm("access_token"), contentType: "application/x-www-form-urlencoded", body: url.Values{"access_token": {"token"}}.Encode(), token: "token"},
+		{name: "form without field", extractor: FromForm("access_token"), contentType: "application/x-www-form-urlencoded", body: url.Values{"token": {"token"}}.Encode()},
+		{name: "param", extractor: FromParam("token"), path: "/files/token", token: "token"},
+		{name: "json", extractor: FromJSON("token"), contentType: "application/json", body: `{"token":"token"}`, token: "token"},
+		{name: "json of other content type", extractor: FromJSON("token"), contentType: "text/plain", body: `{"token":"token"}`},
+		{name: "websocket protocol", extractor: FromWebSocketProtocol, header: map[string]string{"Sec-WebSocket-Protocol": "bearer, token"}, token: "token"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var token string
+			router := gin.New()
+			handler := func(ctx *gin.Context) {
+				token = test.extractor(ctx)
+				ctx.Status(http.StatusOK)
+			}
+			router.Any("/", handler)
+			router.Any("/files/:token", handler)
+			path := test.path
+			if path == "" {
+				path = "/"
+			}
+			req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(test.body))
+			for key, value := range test.header {
+				req.Header.Set(key, value)
+			}
+			if test.contentType != "" {
+				req.Header.Set("Content-Type", test.contentType)
+			}
+			router.ServeHTTP(httptest.NewRecorder(), req)
+			if token != test.token {
+				t.Errorf("token want %q but get %q", test.token, token)
+			}
+		})
+	}
+}
+
+func TestFromJSONKeepsBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/", func(ctx *gin.Context) {
+		if token := FromJSON("token")(ctx); token != "token" {
+			t.Errorf("token want token but get %q", token)
+		}
+		ctx.Next()
+	}, func(ctx *gin.Context) {
+		var body struct {
+			Token string `json:"token"`
+			Name  string `json:"name"`
+		}
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			t.Errorf("bind json of the next handler %v", err)
+		}
+		ctx.String(http.StatusOK, body.Name)
+	})
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"token":"token","name":"name"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if body, _ := io.ReadAll(w.Body); string(body) != "name" {
+		t.Errorf("body of the next handler want name but get %q", body)
+	}
+}
@@ -0,0 +1,116 @@
+package gin
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/snowlyg/multi"
+)
+
+// Guard reports whether the claims decoded by a verifier are allowed.
+type Guard func(ctx *gin.Context) bool
+
+// ForbiddenHandler is called when a guard rejects the request.
+var ForbiddenHandler = func(ctx *gin.Context) {
+	DefaultErrorHandler(ctx, multi.NewVerifyError(multi.ErrCodeForbidden, nil))
+}
+
+// AuthorityType allows the claims of any of the authority types.
+func AuthorityType(authorityTypes ...int) Guard {
+	return func(ctx *gin.Context) bool {
+		v := Get(ctx)
+		if v == nil {
+			return false
+		}
+		for _, authorityType := range authorityTypes {
+			if v.AuthorityType == authorityType {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// AnyAuthorityId allows the claims which hold any of the authority ids.
+func AnyAuthorityId(authorityIds ...string) Guard {
+	return func(ctx *gin.Context) bool {
+		for _, id := range GetAuthorityId(ctx) {
+			for _, authorityId := range authorityIds {
+				if id == authorityId {
+					return true
+				}
+			}
+		}
+		return false
+	}
+}
+
+// Tenancy allows the claims of any of the tenancies,
+// or of any tenancy when no tenancy id is given.
+func Tenancy(tenancyIds ...uint) Guard {
+	return func(ctx *gin.Context) bool {
+		tenancyId := GetTenancyId(ctx)
+		if tenancyId == 0 {
+			return false
+		}
+		if len(tenancyIds) == 0 {
+			return true
+		}
+		for _, id := range tenancyIds {
+			if tenancyId == id {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// And allows the request when all of the guards allow it.
+func And(guards ...Guard) Guard {
+	return func(ctx *gin.Context) bool {
+		for _, guard := range guards {
+			if !guard(ctx) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or allows the request when any of the guards allows it.
+func Or(guards ...Guard) Guard {
+	return func(ctx *gin.Context) bool {
+		for _, guard := range guards {
+			if guard(ctx) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Require returns a middleware which calls ForbiddenHandler unless all of the guards allow the request.
+// It must be registered after Verifier.Verify.
+func Require(guards ...Guard) gin.HandlerFunc {
+	guard := And(guards...)
+	return func(ctx *gin.Context) {
+		if !guard(ctx) {
+			ForbiddenHandler(ctx)
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// RequireAuthorityType
+func RequireAuthorityType(authorityTypes ...int) gin.HandlerFunc {
+	return Require(AuthorityType(authorityTypes...))
+}
+
+// RequireAnyAuthorityId
+func RequireAnyAuthorityId(authorityIds ...string) gin.HandlerFunc {
+	return Require(AnyAuthorityId(authorityIds...))
+}
+
+// RequireTenancy
+func RequireTenancy(tenancyIds ...uint) gin.HandlerFunc {
+	return Require(Tenancy(tenancyIds...))
+}
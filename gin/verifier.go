@@ -1,10 +1,6 @@
 package gin
 
 import (
-	"net/http"
-	"strconv"
-	"strings"
-
 	"github.com/gin-gonic/gin"
 	"github.com/snowlyg/multi"
 )
@@ -14,16 +10,30 @@ const (
 	verifiedTokenContextKey = "gin.multi.token"
 )
 
-// Get returns the claims decoded by a verifier.
+// Get returns the standard claims decoded by a verifier.
 func Get(ctx *gin.Context) *multi.MultiClaims {
 	v, b := ctx.Get(claimsContextKey)
 	if !b {
 		return nil
 	}
-	tok, ok := v.(*multi.MultiClaims)
+	tok, ok := v.(multi.Claims)
 	if !ok {
 		return nil
 	}
+	return tok.Standard()
+}
+
+// GetClaims returns the custom claims decoded by a VerifierOf[T].
+func GetClaims[T multi.Claims](ctx *gin.Context) T {
+	var empty T
+	v, b := ctx.Get(claimsContextKey)
+	if !b {
+		return empty
+	}
+	tok, ok := v.(T)
+	if !ok {
+		return empty
+	}
 	return tok
 }
 
@@ -37,23 +47,12 @@ func GetAuthorityType(ctx *gin.Context) int {
 
 // GetAuthorityId 角色id
 func GetAuthorityId(ctx *gin.Context) []string {
-	if v := Get(ctx); v != nil {
-		return strings.Split(v.AuthorityId, multi.AuthorityTypeSplit)
-	}
-	return nil
+	return Get(ctx).AuthorityIds()
 }
 
 // GetUserId 用户id
 func GetUserId(ctx *gin.Context) uint {
-	v := Get(ctx)
-	if v == nil {
-		return 0
-	}
-	id, err := strconv.Atoi(v.Id)
-	if err != nil {
-		return 0
-	}
-	return uint(id)
+	return Get(ctx).UserId()
 }
 
 // GetUsername 用户名
@@ -108,39 +107,60 @@ func GetVerifiedToken(ctx *gin.Context) []byte {
 }
 
 func IsRole(ctx *gin.Context, authorityType int) bool {
-	v := GetVerifiedToken(ctx)
-	if v == nil {
-		return false
-	}
-	b, err := multi.AuthDriver.IsRole(string(v), authorityType)
-	if err != nil {
-		return false
+	if v := Get(ctx); v != nil {
+		return v.AuthorityType == authorityType
 	}
-	return b
+	return false
+}
+
+// IsAnonymous reports whether the request passed VerifierOf.VerifyOptional without a token.
+func IsAnonymous(ctx *gin.Context) bool {
+	return Get(ctx) == nil
 }
 
 func IsAdmin(ctx *gin.Context) bool {
 	return IsRole(ctx, multi.AdminAuthority)
 }
 
-type Verifier struct {
+type Verifier = VerifierOf[*multi.MultiClaims]
+
+// VerifierOf verifies the token and stores the claims of type T.
+// The global multi.AuthDriver is used when Driver is nil.
+type VerifierOf[T multi.Claims] struct {
+	Driver       multi.AuthenticationOf[T]
 	Extractors   []TokenExtractor
 	Validators   []multi.TokenValidator
 	ErrorHandler func(ctx *gin.Context, err error)
 }
 
+// DefaultErrorHandler writes the verification error as the problem+json response,
+// the WWW-Authenticate header is set for 401 responses.
+func DefaultErrorHandler(ctx *gin.Context, err error) {
+	vErr := multi.AsVerifyError(err)
+	if challenge := vErr.WWWAuthenticate(); challenge != "" {
+		ctx.Header("WWW-Authenticate", challenge)
+	}
+	body := vErr.ProblemJSON()
+	ctx.Error(err)
+	ctx.Data(vErr.Status, multi.ProblemContentType, body)
+	ctx.Abort()
+}
+
 func NewVerifier(validators ...multi.TokenValidator) *Verifier {
-	return &Verifier{
-		Extractors: []TokenExtractor{FromHeader, FromQuery},
-		ErrorHandler: func(ctx *gin.Context, err error) {
-			ctx.AbortWithError(http.StatusUnauthorized, err)
-		},
-		Validators: validators,
+	return NewVerifierOf[*multi.MultiClaims](nil, validators...)
+}
+
+func NewVerifierOf[T multi.Claims](driver multi.AuthenticationOf[T], validators ...multi.TokenValidator) *VerifierOf[T] {
+	return &VerifierOf[T]{
+		Driver:       driver,
+		Extractors:   []TokenExtractor{FromHeader, FromQuery},
+		ErrorHandler: DefaultErrorHandler,
+		Validators:   validators,
 	}
 }
 
 // Invalidate
-func (v *Verifier) invalidate(ctx *gin.Context) {
+func (v *VerifierOf[T]) invalidate(ctx *gin.Context) {
 	if verifiedToken := GetVerifiedToken(ctx); verifiedToken != nil {
 		ctx.Set(claimsContextKey, "")
 		ctx.Set(verifiedTokenContextKey, "")
@@ -148,7 +168,7 @@ func (v *Verifier) invalidate(ctx *gin.Context) {
 }
 
 // RequestToken extracts the token from the
-func (v *Verifier) RequestToken(ctx *gin.Context) (token string) {
+func (v *VerifierOf[T]) RequestToken(ctx *gin.Context) (token string) {
 	for _, extract := range v.Extractors {
 		if token = extract(ctx); token != "" {
 			break // ok we found it.
@@ -157,37 +177,45 @@ func (v *Verifier) RequestToken(ctx *gin.Context) (token string) {
 	return
 }
 
-func (v *Verifier) VerifyToken(token []byte, validators ...multi.TokenValidator) ([]byte, *multi.MultiClaims, error) {
-	if len(token) == 0 {
-		return nil, nil, multi.ErrEmptyToken
-	}
-	var err error
-	for _, validator := range validators {
-		// A token validator can skip the builtin validation and return a nil error,
-		// in that case the previous error is skipped.
-		if err = validator.ValidateToken(token, err); err != nil {
-			break
-		}
-	}
-	if err != nil {
-		// Exit on parsing standard claims error(when Plain is missing) or standard claims validation error or custom validators.
-		return nil, nil, err
-	}
-	rcc, err := multi.AuthDriver.GetMultiClaims(string(token))
-	if err != nil {
-		return nil, nil, err
-	}
-	err = rcc.Valid()
+// VerifyToken verifies the token and returns its claims,
+// the error is a *multi.VerifyError which wraps the cause.
+// The multi.RequestValidators get an empty request, Verify passes the request to them.
+func (v *VerifierOf[T]) VerifyToken(token []byte, validators ...multi.TokenValidator) ([]byte, T, error) {
+	return v.verifyRequest(token, multi.RequestInfo{}, validators...)
+}
+
+// verifyRequest verifies the token by multi.VerifyRequest,
+// the validators of the verifier run before the given ones.
+func (v *VerifierOf[T]) verifyRequest(token []byte, req multi.RequestInfo, validators ...multi.TokenValidator) ([]byte, T, error) {
+	validators = append(append([]multi.TokenValidator{}, v.Validators...), validators...)
+	rcc, err := multi.VerifyRequest(v.Driver, token, req, validators...)
 	if err != nil {
-		return nil, nil, err
+		return nil, rcc, err
 	}
 	return token, rcc, nil
 }
 
-func (v *Verifier) Verify(validators ...multi.TokenValidator) gin.HandlerFunc {
+// Verify returns a middleware which rejects the requests without a valid token.
+func (v *VerifierOf[T]) Verify(validators ...multi.TokenValidator) gin.HandlerFunc {
+	return v.verify(false, validators...)
+}
+
+// VerifyOptional returns a middleware which lets the requests without a token through as anonymous,
+// see IsAnonymous. A present but invalid token is still rejected by the ErrorHandler.
+func (v *VerifierOf[T]) VerifyOptional(validators ...multi.TokenValidator) gin.HandlerFunc {
+	return v.verify(true, validators...)
+}
+
+// verify
+func (v *VerifierOf[T]) verify(optional bool, validators ...multi.TokenValidator) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		token := []byte(v.RequestToken(ctx))
-		verifiedToken, rcc, err := v.VerifyToken(token, validators...)
+		if optional && len(token) == 0 {
+			v.invalidate(ctx)
+			ctx.Next()
+			return
+		}
+		verifiedToken, rcc, err := v.verifyRequest(token, requestInfo(ctx), validators...)
 		if err != nil {
 			v.invalidate(ctx)
 			v.ErrorHandler(ctx, err)
@@ -198,3 +226,12 @@ func (v *Verifier) Verify(validators ...multi.TokenValidator) gin.HandlerFunc {
 		ctx.Next()
 	}
 }
+
+// requestInfo returns the request metadata for the multi.RequestValidators.
+func requestInfo(ctx *gin.Context) multi.RequestInfo {
+	return multi.RequestInfo{
+		Method: ctx.Request.Method,
+		Path:   ctx.Request.URL.Path,
+		IP:     ctx.ClientIP(),
+	}
+}
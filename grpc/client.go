@@ -0,0 +1,52 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// TokenSource returns the token attached to the outgoing calls.
+type TokenSource func(ctx context.Context) (string, error)
+
+// StaticToken returns a TokenSource which always returns the token.
+func StaticToken(token string) TokenSource {
+	return func(ctx context.Context) (string, error) {
+		return token, nil
+	}
+}
+
+// withToken attaches the token to the outgoing metadata as authorization: "Bearer {token}".
+func withToken(ctx context.Context, source TokenSource) (context.Context, error) {
+	token, err := source(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if token == "" {
+		return ctx, nil
+	}
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token), nil
+}
+
+// UnaryClientInterceptor attaches the token to unary calls.
+func UnaryClientInterceptor(source TokenSource) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, err := withToken(ctx, source)
+		if err != nil {
+			return err
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor attaches the token to streaming calls.
+func StreamClientInterceptor(source TokenSource) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, err := withToken(ctx, source)
+		if err != nil {
+			return nil, err
+		}
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
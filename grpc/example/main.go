@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/snowlyg/multi"
+	multi_grpc "github.com/snowlyg/multi/grpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// init 初始化认证驱动
+// 驱动类型： 可选 redis ,local,jwt
+func init() {
+	options := &redis.UniversalOptions{
+		Addrs:       []string{"127.0.0.1:6379"},
+		Password:    "",
+		PoolSize:    10,
+		IdleTimeout: 300 * time.Second,
+	}
+
+	err := multi.InitDriver(&multi.Config{
+		DriverType:      "redis",
+		TokenMaxCount:   10,
+		UniversalClient: redis.NewUniversalClient(options)})
+	if err != nil {
+		panic(fmt.Sprintf("auth is not init get err %v\n", err))
+	}
+}
+
+func main() {
+	verifier := multi_grpc.NewVerifier()
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(verifier.UnaryServerInterceptor()),
+		grpc.StreamInterceptor(verifier.StreamServerInterceptor()),
+	)
+	grpc_health_v1.RegisterHealthServer(server, health.NewServer())
+
+	lis, err := net.Listen("tcp", ":8080")
+	if err != nil {
+		log.Fatal(err)
+	}
+	go server.Serve(lis)
+
+	token, _, err := multi.AuthDriver.GenerateToken(&multi.MultiClaims{
+		Id:            "1",
+		Username:      "your name",
+		AuthorityId:   "your authority id",
+		TenancyId:     1,
+		TenancyName:   "your tenancy name",
+		AuthorityType: multi.AdminAuthority,
+		LoginType:     multi.LoginTypeWeb,
+		AuthType:      multi.AuthPwd,
+		CreationDate:  time.Now().Local().Unix(),
+		ExpiresAt:     time.Now().Local().Add(multi.RedisSessionTimeoutWeb).Unix(),
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// The client attaches authorization: Bearer $token to every call.
+	conn, err := grpc.Dial("127.0.0.1:8080",
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(multi_grpc.UnaryClientInterceptor(multi_grpc.StaticToken(token))),
+		grpc.WithStreamInterceptor(multi_grpc.StreamClientInterceptor(multi_grpc.StaticToken(token))),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close()
+
+	resp, err := grpc_health_v1.NewHealthClient(conn).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("status=%s\n", resp.Status)
+}
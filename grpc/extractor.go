@@ -0,0 +1,36 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/snowlyg/multi"
+	"google.golang.org/grpc/metadata"
+)
+
+// TokenExtractor is a function that takes a context as input and returns
+// a token. An empty string should be returned if no token found
+// without additional information.
+type TokenExtractor func(context.Context) string
+
+// FromAuthorization is a token extractor.
+// It reads the token from the authorization incoming metadata of form:
+// authorization: "Bearer {token}".
+func FromAuthorization(ctx context.Context) string {
+	return multi.ParseHeaderToken(FromMetadata("authorization")(ctx), "Bearer")
+}
+
+// FromMetadata is a token extractor.
+// It reads the token from the first value of the given incoming metadata key.
+func FromMetadata(key string) TokenExtractor {
+	return func(ctx context.Context) string {
+		md, ok := metadata.FromIncomingContext(ctx)
+		if !ok {
+			return ""
+		}
+		values := md.Get(key)
+		if len(values) == 0 {
+			return ""
+		}
+		return values[0]
+	}
+}
@@ -0,0 +1,239 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/snowlyg/multi"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type contextKey string
+
+const (
+	claimsContextKey        contextKey = "grpc.multi.claims"
+	verifiedTokenContextKey contextKey = "grpc.multi.token"
+)
+
+// Get returns the standard claims decoded by a verifier.
+func Get(ctx context.Context) *multi.MultiClaims {
+	if v, ok := ctx.Value(claimsContextKey).(multi.Claims); ok {
+		return v.Standard()
+	}
+	return nil
+}
+
+// GetClaims returns the custom claims decoded by a VerifierOf[T].
+func GetClaims[T multi.Claims](ctx context.Context) T {
+	var empty T
+	if v, ok := ctx.Value(claimsContextKey).(T); ok {
+		return v
+	}
+	return empty
+}
+
+// GetAuthorityType 角色类型
+func GetAuthorityType(ctx context.Context) int {
+	if v := Get(ctx); v != nil {
+		return v.AuthorityType
+	}
+	return 0
+}
+
+// GetAuthorityId 角色id
+func GetAuthorityId(ctx context.Context) []string {
+	return Get(ctx).AuthorityIds()
+}
+
+// GetUserId 用户id
+func GetUserId(ctx context.Context) uint {
+	return Get(ctx).UserId()
+}
+
+// GetUsername 用户名
+func GetUsername(ctx context.Context) string {
+	if v := Get(ctx); v != nil {
+		return v.Username
+	}
+	return ""
+}
+
+// GetTenancyId 商户id
+func GetTenancyId(ctx context.Context) uint {
+	if v := Get(ctx); v != nil {
+		return v.TenancyId
+	}
+	return 0
+}
+
+// GetTenancyName 商户名称
+func GetTenancyName(ctx context.Context) string {
+	if v := Get(ctx); v != nil {
+		return v.TenancyName
+	}
+	return ""
+}
+
+// GetCreationDate 登录时间
+func GetCreationDate(ctx context.Context) int64 {
+	if v := Get(ctx); v != nil {
+		return v.CreationDate
+	}
+	return 0
+}
+
+// GetExpiresIn 有效期
+func GetExpiresIn(ctx context.Context) int64 {
+	if v := Get(ctx); v != nil {
+		return v.ExpiresAt
+	}
+	return 0
+}
+
+func GetVerifiedToken(ctx context.Context) []byte {
+	if tok, ok := ctx.Value(verifiedTokenContextKey).([]byte); ok {
+		return tok
+	}
+	return nil
+}
+
+func IsRole(ctx context.Context, authorityType int) bool {
+	if v := Get(ctx); v != nil {
+		return v.AuthorityType == authorityType
+	}
+	return false
+}
+
+func IsAdmin(ctx context.Context) bool {
+	return IsRole(ctx, multi.AdminAuthority)
+}
+
+type Verifier = VerifierOf[*multi.MultiClaims]
+
+// VerifierOf verifies the token and stores the claims of type T.
+// The global multi.AuthDriver is used when Driver is nil.
+type VerifierOf[T multi.Claims] struct {
+	Driver       multi.AuthenticationOf[T]
+	Extractors   []TokenExtractor
+	Validators   []multi.TokenValidator
+	ErrorHandler func(ctx context.Context, err error) error
+}
+
+// DefaultErrorHandler returns the status of the verification error,
+// the stable code of the error is the status message prefix.
+func DefaultErrorHandler(ctx context.Context, err error) error {
+	vErr := multi.AsVerifyError(err)
+	switch vErr.Status {
+	case http.StatusForbidden:
+		return status.Error(codes.PermissionDenied, vErr.Error())
+	case http.StatusInternalServerError:
+		return status.Error(codes.Internal, vErr.Error())
+	default:
+		return status.Error(codes.Unauthenticated, vErr.Error())
+	}
+}
+
+func NewVerifier(validators ...multi.TokenValidator) *Verifier {
+	return NewVerifierOf[*multi.MultiClaims](nil, validators...)
+}
+
+func NewVerifierOf[T multi.Claims](driver multi.AuthenticationOf[T], validators ...multi.TokenValidator) *VerifierOf[T] {
+	return &VerifierOf[T]{
+		Driver:       driver,
+		Extractors:   []TokenExtractor{FromAuthorization},
+		ErrorHandler: DefaultErrorHandler,
+		Validators:   validators,
+	}
+}
+
+// RequestToken extracts the token from the incoming metadata.
+func (v *VerifierOf[T]) RequestToken(ctx context.Context) (token string) {
+	for _, extract := range v.Extractors {
+		if token = extract(ctx); token != "" {
+			break // ok we found it.
+		}
+	}
+	return
+}
+
+// VerifyToken verifies the token and returns its claims,
+// the error is a *multi.VerifyError which wraps the cause.
+// The multi.RequestValidators get an empty request, the interceptors passes the request to them.
+func (v *VerifierOf[T]) VerifyToken(token []byte, validators ...multi.TokenValidator) ([]byte, T, error) {
+	return v.verifyRequest(token, multi.RequestInfo{}, validators...)
+}
+
+// verifyRequest verifies the token by multi.VerifyRequest,
+// the validators of the verifier run before the given ones.
+func (v *VerifierOf[T]) verifyRequest(token []byte, req multi.RequestInfo, validators ...multi.TokenValidator) ([]byte, T, error) {
+	validators = append(append([]multi.TokenValidator{}, v.Validators...), validators...)
+	rcc, err := multi.VerifyRequest(v.Driver, token, req, validators...)
+	if err != nil {
+		return nil, rcc, err
+	}
+	return token, rcc, nil
+}
+
+// verify returns the context carrying the claims of the verified token.
+func (v *VerifierOf[T]) verify(ctx context.Context, fullMethod string, validators []multi.TokenValidator) (context.Context, error) {
+	token := []byte(v.RequestToken(ctx))
+	verifiedToken, rcc, err := v.verifyRequest(token, requestInfo(ctx, fullMethod), validators...)
+	if err != nil {
+		return nil, v.ErrorHandler(ctx, err)
+	}
+	ctx = context.WithValue(ctx, claimsContextKey, rcc)
+	ctx = context.WithValue(ctx, verifiedTokenContextKey, verifiedToken)
+	return ctx, nil
+}
+
+// requestInfo returns the call metadata for the multi.RequestValidators,
+// the path is the full method of the call, e.g. /helloworld.Greeter/SayHello, and the ip is the peer address.
+func requestInfo(ctx context.Context, fullMethod string) multi.RequestInfo {
+	req := multi.RequestInfo{
+		Method: http.MethodPost,
+		Path:   fullMethod,
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		req.IP = p.Addr.String()
+		if ip, _, err := net.SplitHostPort(req.IP); err == nil {
+			req.IP = ip
+		}
+	}
+	return req
+}
+
+// UnaryServerInterceptor verifies the token of unary calls.
+func (v *VerifierOf[T]) UnaryServerInterceptor(validators ...multi.TokenValidator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := v.verify(ctx, info.FullMethod, validators)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor verifies the token of streaming calls.
+func (v *VerifierOf[T]) StreamServerInterceptor(validators ...multi.TokenValidator) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := v.verify(ss.Context(), info.FullMethod, validators)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// serverStream overrides the context of the wrapped stream.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
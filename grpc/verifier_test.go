@@ -0,0 +1,97 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/snowlyg/multi"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newTestClaims returns the valid claims of the admin user.
+func newTestClaims(id uint) *multi.MultiClaims {
+	return multi.New(&multi.Multi{
+		Id:            id,
+		Username:      "username",
+		AuthorityIds:  []string{"999"},
+		AuthorityType: multi.AdminAuthority,
+		LoginType:     multi.LoginTypeWeb,
+		AuthType:      multi.AuthPwd,
+		ExpiresAt:     time.Now().Add(multi.RedisSessionTimeoutWeb).Unix(),
+	})
+}
+
+// healthServer serves the calls of the user 1 only, so the claims in the context are checked.
+type healthServer struct {
+	healthpb.UnimplementedHealthServer
+}
+
+func (s *healthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if GetUserId(ctx) != 1 {
+		return nil, status.Error(codes.FailedPrecondition, "claims not in context")
+	}
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+}
+
+// newTestClient serves the health service by the verifier over an in-memory connection.
+func newTestClient(t *testing.T, verifier *Verifier, token string, validators ...multi.TokenValidator) healthpb.HealthClient {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(grpc.UnaryInterceptor(verifier.UnaryServerInterceptor(validators...)))
+	healthpb.RegisterHealthServer(server, &healthServer{})
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+	}
+	if token != "" {
+		opts = append(opts, grpc.WithUnaryInterceptor(UnaryClientInterceptor(StaticToken(token))))
+	}
+	conn, err := grpc.Dial("bufnet", opts...)
+	if err != nil {
+		t.Fatalf("dial %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return healthpb.NewHealthClient(conn)
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	driver := multi.NewLocalAuth()
+	token, _, err := driver.GenerateToken(newTestClaims(1))
+	if err != nil {
+		t.Fatalf("generate token %v", err)
+	}
+	defer driver.DelUserTokenCache(token)
+	verifier := NewVerifierOf[*multi.MultiClaims](driver)
+
+	tests := []struct {
+		name       string
+		token      string
+		validators []multi.TokenValidator
+		code       codes.Code
+	}{
+		{name: "missing token", code: codes.Unauthenticated},
+		{name: "invalid token", token: "invalid", code: codes.Unauthenticated},
+		{name: "valid token", token: token, code: codes.OK},
+		{name: "rejected by validator", token: token, validators: []multi.TokenValidator{multi.Expected{TenancyId: 9}}, code: codes.PermissionDenied},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := newTestClient(t, verifier, test.token, test.validators...)
+			_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+			if code := status.Code(err); code != test.code {
+				t.Errorf("code want %s but get %s: %v", test.code, code, err)
+			}
+		})
+	}
+}
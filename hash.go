@@ -0,0 +1,46 @@
+package multi
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
+
+// TokenHasher hashes the tokens before they reach the store,
+// the drivers keep the keyed hash in the key names and the indexes instead of the token,
+// so the sessions can not be hijacked with the read access to the store or its backups.
+// The tokens can not be recovered from the hashes, so GetTokenByClaims, GetTenancyTokens
+// and GetAuthorityTokens return the hashes which only identify the sessions.
+type TokenHasher struct {
+	key []byte
+	// MigrateLegacy also finds the sessions stored by the plain tokens before hashing was enabled,
+	// the redis driver moves them to the hashed keys on first use. The local cache is not persistent,
+	// so it has no legacy sessions.
+	MigrateLegacy bool
+}
+
+// NewTokenHasher returns the token hasher of the key, the key is 32 bytes at least
+// and must be shared by the instances of the same store.
+func NewTokenHasher(key []byte) (*TokenHasher, error) {
+	if len(key) < 32 {
+		return nil, errors.New("mutil: token hasher key is shorter than 32 bytes")
+	}
+	return &TokenHasher{key: key}, nil
+}
+
+// Hash returns the hex HMAC-SHA256 of the token.
+func (h *TokenHasher) Hash(token string) string {
+	mac := hmac.New(sha256.New, h.key)
+	mac.Write([]byte(token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// hashToken returns the id of the session of the token in the store,
+// the token itself if the hasher is nil.
+func hashToken(h *TokenHasher, token string) string {
+	if h == nil || token == "" {
+		return token
+	}
+	return h.Hash(token)
+}
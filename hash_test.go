@@ -0,0 +1,159 @@
+package multi
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+)
+
+var hasherKey = bytes.Repeat([]byte("h"), 32)
+
+func TestTokenHasher(t *testing.T) {
+	h, err := NewTokenHasher(hasherKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Hash("token") != h.Hash("token") {
+		t.Error("hash of the same token want equal")
+	}
+	if h.Hash("token") == h.Hash("token2") || h.Hash("token") == "token" {
+		t.Error("hash of the tokens want different")
+	}
+	other, _ := NewTokenHasher(bytes.Repeat([]byte("o"), 32))
+	if h.Hash("token") == other.Hash("token") {
+		t.Error("hash of the keys want different")
+	}
+	if _, err := NewTokenHasher([]byte("short")); err == nil {
+		t.Error("short key want error")
+	}
+}
+
+func TestLocalHashedToken(t *testing.T) {
+	h, _ := NewTokenHasher(hasherKey)
+	auth := NewLocalAuth()
+	auth.Hasher = h
+	token, _, err := auth.GenerateToken(customClaims)
+	if err != nil {
+		t.Fatalf("generate token %v", err)
+	}
+	if _, found := auth.Cache.Get(GtSessionTokenPrefix + token); found {
+		t.Error("token want not stored verbatim")
+	}
+	if _, found := auth.Cache.Get(GtSessionTokenPrefix + h.Hash(token)); !found {
+		t.Error("token hash want stored")
+	}
+	if _, err := auth.GetMultiClaims(token); err != nil {
+		t.Errorf("get custom claims %v", err)
+	}
+	if _, err := auth.GetMultiClaims(h.Hash(token)); err == nil {
+		t.Error("get custom claims by hash want error")
+	}
+	secret, err := auth.SetCsrfSecret(token)
+	if err != nil {
+		t.Fatalf("set csrf secret %v", err)
+	}
+	if got, _ := auth.GetCsrfSecret(token); got != secret {
+		t.Errorf("get csrf secret want %s but get %s", secret, got)
+	}
+
+	revoked := false
+	cancel := WatchRevoked(auth, token, func() { revoked = true })
+	defer cancel()
+
+	if err := auth.DelUserTokenCache(token); err != nil {
+		t.Errorf("del user token cache %v", err)
+	}
+	if !revoked {
+		t.Error("watcher want notified by the hash")
+	}
+	if _, err := auth.GetMultiClaims(token); err == nil {
+		t.Error("get custom claims after del want error")
+	}
+}
+
+func TestRedisHashedToken(t *testing.T) {
+	h, _ := NewTokenHasher(hasherKey)
+	client := redis.NewUniversalClient(options)
+	redisAuth, err := NewRedisAuth(client)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	redisAuth.Hasher = h
+	defer redisAuth.CleanUserTokenCache(redisClaims.AuthorityType, redisClaims.Id)
+
+	token, _, err := redisAuth.GenerateToken(redisClaims)
+	if err != nil {
+		t.Fatalf("generate token %v", err)
+	}
+	if count, _ := client.Exists(context.Background(), GtSessionTokenPrefix+token).Result(); count != 0 {
+		t.Error("token want not stored verbatim")
+	}
+	if count, _ := client.Exists(context.Background(), GtSessionTokenPrefix+h.Hash(token)).Result(); count != 1 {
+		t.Error("token hash want stored")
+	}
+	if ok, _ := client.SIsMember(context.Background(), ruserKey, token).Result(); ok {
+		t.Error("token want not indexed verbatim")
+	}
+	if _, err := redisAuth.GetMultiClaims(token); err != nil {
+		t.Errorf("get custom claims %v", err)
+	}
+
+	again, _, err := redisAuth.GenerateToken(redisClaims)
+	if err != nil {
+		t.Fatalf("generate token again %v", err)
+	}
+	if again == token {
+		t.Error("token want a new session as it can not be recovered from the hash")
+	}
+	if _, err := redisAuth.GetMultiClaims(token); err != nil {
+		t.Errorf("get custom claims of the existing session want nil but get %v", err)
+	}
+	for _, tok := range []string{token, again} {
+		if err := redisAuth.DelUserTokenCache(tok); err != nil {
+			t.Errorf("del user token cache %v", err)
+		}
+	}
+}
+
+func TestRedisMigrateLegacyToken(t *testing.T) {
+	client := redis.NewUniversalClient(options)
+	legacyAuth, err := NewRedisAuth(client)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer legacyAuth.CleanUserTokenCache(redisClaims.AuthorityType, redisClaims.Id)
+	token, _, err := legacyAuth.GenerateToken(redisClaims)
+	if err != nil {
+		t.Fatalf("generate token %v", err)
+	}
+
+	h, _ := NewTokenHasher(hasherKey)
+	hashedAuth, _ := NewRedisAuth(client)
+	hashedAuth.Hasher = h
+	if _, err := hashedAuth.GetMultiClaims(token); err == nil {
+		t.Error("get legacy claims without migration want error")
+	}
+
+	h.MigrateLegacy = true
+	cla, err := hashedAuth.GetMultiClaims(token)
+	if err != nil {
+		t.Fatalf("get legacy claims %v", err)
+	}
+	if cla.Id != redisClaims.Id {
+		t.Errorf("get legacy claims id want %s but get %s", redisClaims.Id, cla.Id)
+	}
+	if count, _ := client.Exists(context.Background(), GtSessionTokenPrefix+token).Result(); count != 0 {
+		t.Error("legacy key want removed")
+	}
+	if ok, _ := client.SIsMember(context.Background(), ruserKey, h.Hash(token)).Result(); !ok {
+		t.Error("token hash want indexed")
+	}
+	if ttl, _ := client.TTL(context.Background(), GtSessionTokenPrefix+h.Hash(token)).Result(); ttl <= 0 {
+		t.Errorf("migrated session want expire but get ttl %v", ttl)
+	}
+	if err := hashedAuth.DelUserTokenCache(token); err != nil {
+		t.Errorf("del user token cache %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package hertz
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/snowlyg/multi"
+)
+
+// TokenExtractor is a function that takes a context as input and returns
+// a token. An empty string should be returned if no token found
+// without additional information.
+type TokenExtractor func(*app.RequestContext) string
+
+// FromHeader is a token extractor.
+// It reads the token from the Authorization request header of form:
+// Authorization: "Bearer {token}".
+func FromHeader(ctx *app.RequestContext) string {
+	return multi.ParseHeaderToken(string(ctx.GetHeader("Authorization")), "Bearer")
+}
+
+// FromQuery is a token extractor.
+// It reads the token from the "token" url query parameter.
+func FromQuery(ctx *app.RequestContext) string {
+	return ctx.Query("token")
+}
+
+// FromJSON is a token extractor.
+// Reads a json request body and extracts the json based on the given field.
+// The request content-type should contain the: application/json header value, otherwise
+// this method will not try to read the body.
+// The body is kept by hertz, so the handler can bind it again.
+func FromJSON(jsonKey string) TokenExtractor {
+	return func(ctx *app.RequestContext) string {
+		if !strings.HasPrefix(string(ctx.ContentType()), "application/json") {
+			return ""
+		}
+
+		body, err := ctx.Body()
+		if err != nil {
+			return ""
+		}
+
+		var m map[string]interface{}
+		if err := json.Unmarshal(body, &m); err != nil {
+			return ""
+		}
+
+		if m == nil {
+			return ""
+		}
+
+		v, ok := m[jsonKey]
+		if !ok {
+			return ""
+		}
+
+		tok, ok := v.(string)
+		if !ok {
+			return ""
+		}
+
+		return tok
+	}
+}
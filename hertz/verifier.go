@@ -0,0 +1,217 @@
+package hertz
+
+import (
+	"context"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/snowlyg/multi"
+)
+
+const (
+	claimsContextKey        = "hertz.multi.claims"
+	verifiedTokenContextKey = "hertz.multi.token"
+)
+
+// Get returns the standard claims decoded by a verifier.
+func Get(ctx *app.RequestContext) *multi.MultiClaims {
+	v, b := ctx.Get(claimsContextKey)
+	if !b {
+		return nil
+	}
+	tok, ok := v.(multi.Claims)
+	if !ok {
+		return nil
+	}
+	return tok.Standard()
+}
+
+// GetClaims returns the custom claims decoded by a VerifierOf[T].
+func GetClaims[T multi.Claims](ctx *app.RequestContext) T {
+	var empty T
+	v, b := ctx.Get(claimsContextKey)
+	if !b {
+		return empty
+	}
+	tok, ok := v.(T)
+	if !ok {
+		return empty
+	}
+	return tok
+}
+
+// GetAuthorityType 角色类型
+func GetAuthorityType(ctx *app.RequestContext) int {
+	if v := Get(ctx); v != nil {
+		return v.AuthorityType
+	}
+	return 0
+}
+
+// GetAuthorityId 角色id
+func GetAuthorityId(ctx *app.RequestContext) []string {
+	return Get(ctx).AuthorityIds()
+}
+
+// GetUserId 用户id
+func GetUserId(ctx *app.RequestContext) uint {
+	return Get(ctx).UserId()
+}
+
+// GetUsername 用户名
+func GetUsername(ctx *app.RequestContext) string {
+	if v := Get(ctx); v != nil {
+		return v.Username
+	}
+	return ""
+}
+
+// GetTenancyId 商户id
+func GetTenancyId(ctx *app.RequestContext) uint {
+	if v := Get(ctx); v != nil {
+		return v.TenancyId
+	}
+	return 0
+}
+
+// GetTenancyName 商户名称
+func GetTenancyName(ctx *app.RequestContext) string {
+	if v := Get(ctx); v != nil {
+		return v.TenancyName
+	}
+	return ""
+}
+
+// GetCreationDate 登录时间
+func GetCreationDate(ctx *app.RequestContext) int64 {
+	if v := Get(ctx); v != nil {
+		return v.CreationDate
+	}
+	return 0
+}
+
+// GetExpiresIn 有效期
+func GetExpiresIn(ctx *app.RequestContext) int64 {
+	if v := Get(ctx); v != nil {
+		return v.ExpiresAt
+	}
+	return 0
+}
+
+func GetVerifiedToken(ctx *app.RequestContext) []byte {
+	v, b := ctx.Get(verifiedTokenContextKey)
+	if !b {
+		return nil
+	}
+	if tok, ok := v.([]byte); ok {
+		return tok
+	}
+	return nil
+}
+
+func IsRole(ctx *app.RequestContext, authorityType int) bool {
+	if v := Get(ctx); v != nil {
+		return v.AuthorityType == authorityType
+	}
+	return false
+}
+
+func IsAdmin(ctx *app.RequestContext) bool {
+	return IsRole(ctx, multi.AdminAuthority)
+}
+
+type Verifier = VerifierOf[*multi.MultiClaims]
+
+// VerifierOf verifies the token and stores the claims of type T.
+// The global multi.AuthDriver is used when Driver is nil.
+type VerifierOf[T multi.Claims] struct {
+	Driver       multi.AuthenticationOf[T]
+	Extractors   []TokenExtractor
+	Validators   []multi.TokenValidator
+	ErrorHandler func(ctx *app.RequestContext, err error)
+}
+
+// DefaultErrorHandler writes the verification error as the problem+json response,
+// the WWW-Authenticate header is set for 401 responses.
+func DefaultErrorHandler(ctx *app.RequestContext, err error) {
+	vErr := multi.AsVerifyError(err)
+	if challenge := vErr.WWWAuthenticate(); challenge != "" {
+		ctx.Header("WWW-Authenticate", challenge)
+	}
+	body := vErr.ProblemJSON()
+	ctx.Error(err)
+	ctx.Data(vErr.Status, multi.ProblemContentType, body)
+	ctx.Abort()
+}
+
+func NewVerifier(validators ...multi.TokenValidator) *Verifier {
+	return NewVerifierOf[*multi.MultiClaims](nil, validators...)
+}
+
+func NewVerifierOf[T multi.Claims](driver multi.AuthenticationOf[T], validators ...multi.TokenValidator) *VerifierOf[T] {
+	return &VerifierOf[T]{
+		Driver:       driver,
+		Extractors:   []TokenExtractor{FromHeader, FromQuery},
+		ErrorHandler: DefaultErrorHandler,
+		Validators:   validators,
+	}
+}
+
+// Invalidate
+func (v *VerifierOf[T]) invalidate(ctx *app.RequestContext) {
+	if verifiedToken := GetVerifiedToken(ctx); verifiedToken != nil {
+		ctx.Set(claimsContextKey, "")
+		ctx.Set(verifiedTokenContextKey, "")
+	}
+}
+
+// RequestToken extracts the token from the
+func (v *VerifierOf[T]) RequestToken(ctx *app.RequestContext) (token string) {
+	for _, extract := range v.Extractors {
+		if token = extract(ctx); token != "" {
+			break // ok we found it.
+		}
+	}
+	return
+}
+
+// VerifyToken verifies the token and returns its claims,
+// the error is a *multi.VerifyError which wraps the cause.
+// The multi.RequestValidators get an empty request, Verify passes the request to them.
+func (v *VerifierOf[T]) VerifyToken(token []byte, validators ...multi.TokenValidator) ([]byte, T, error) {
+	return v.verifyRequest(token, multi.RequestInfo{}, validators...)
+}
+
+// verifyRequest verifies the token by multi.VerifyRequest,
+// the validators of the verifier run before the given ones.
+func (v *VerifierOf[T]) verifyRequest(token []byte, req multi.RequestInfo, validators ...multi.TokenValidator) ([]byte, T, error) {
+	validators = append(append([]multi.TokenValidator{}, v.Validators...), validators...)
+	rcc, err := multi.VerifyRequest(v.Driver, token, req, validators...)
+	if err != nil {
+		return nil, rcc, err
+	}
+	return token, rcc, nil
+}
+
+func (v *VerifierOf[T]) Verify(validators ...multi.TokenValidator) app.HandlerFunc {
+	return func(c context.Context, ctx *app.RequestContext) {
+		token := []byte(v.RequestToken(ctx))
+		verifiedToken, rcc, err := v.verifyRequest(token, requestInfo(ctx), validators...)
+		if err != nil {
+			v.invalidate(ctx)
+			v.ErrorHandler(ctx, err)
+			return
+		}
+		ctx.Set(claimsContextKey, rcc)
+		ctx.Set(verifiedTokenContextKey, verifiedToken)
+		ctx.Next(c)
+	}
+}
+
+// requestInfo returns the request metadata for the multi.RequestValidators.
+func requestInfo(ctx *app.RequestContext) multi.RequestInfo {
+	return multi.RequestInfo{
+		Method: string(ctx.Method()),
+		Path:   string(ctx.Path()),
+		IP:     ctx.ClientIP(),
+	}
+}
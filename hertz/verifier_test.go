@@ -0,0 +1,77 @@
+package hertz
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/route"
+	"github.com/snowlyg/multi"
+)
+
+// newTestClaims returns the valid claims of the admin user.
+func newTestClaims(id uint) *multi.MultiClaims {
+	return multi.New(&multi.Multi{
+		Id:            id,
+		Username:      "username",
+		AuthorityIds:  []string{"999"},
+		AuthorityType: multi.AdminAuthority,
+		LoginType:     multi.LoginTypeWeb,
+		AuthType:      multi.AuthPwd,
+		ExpiresAt:     time.Now().Add(multi.RedisSessionTimeoutWeb).Unix(),
+	})
+}
+
+func TestVerify(t *testing.T) {
+	driver := multi.NewLocalAuth()
+	token, _, err := driver.GenerateToken(newTestClaims(1))
+	if err != nil {
+		t.Fatalf("generate token %v", err)
+	}
+	defer driver.DelUserTokenCache(token)
+
+	verifier := NewVerifierOf[*multi.MultiClaims](driver)
+	handler := func(c context.Context, ctx *app.RequestContext) {
+		ctx.String(http.StatusOK, strconv.FormatUint(uint64(GetUserId(ctx)), 10))
+	}
+	engine := route.NewEngine(config.NewOptions(nil))
+	engine.GET("/", verifier.Verify(), handler)
+	engine.GET("/tenancy", verifier.Verify(multi.Expected{TenancyId: 9}), handler)
+
+	tests := []struct {
+		name      string
+		path      string
+		token     string
+		status    int
+		challenge string
+		body      string
+	}{
+		{name: "missing token", path: "/", status: http.StatusUnauthorized, challenge: "Bearer"},
+		{name: "invalid token", path: "/", token: "invalid", status: http.StatusUnauthorized, challenge: `Bearer error="invalid_token", error_description="token_revoked"`},
+		{name: "valid token", path: "/", token: token, status: http.StatusOK, body: "1"},
+		{name: "rejected by validator", path: "/tenancy", token: token, status: http.StatusForbidden},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var headers []ut.Header
+			if test.token != "" {
+				headers = append(headers, ut.Header{Key: "Authorization", Value: "Bearer " + test.token})
+			}
+			resp := ut.PerformRequest(engine, http.MethodGet, test.path, nil, headers...).Result()
+			if resp.StatusCode() != test.status {
+				t.Fatalf("status want %d but get %d", test.status, resp.StatusCode())
+			}
+			if challenge := string(resp.Header.Peek("WWW-Authenticate")); challenge != test.challenge {
+				t.Errorf("WWW-Authenticate want %q but get %q", test.challenge, challenge)
+			}
+			if test.body != "" && string(resp.Body()) != test.body {
+				t.Errorf("user id in context want %s but get %s", test.body, resp.Body())
+			}
+		})
+	}
+}
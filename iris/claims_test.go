@@ -0,0 +1,24 @@
+package iris
+
+import (
+	"time"
+
+	"github.com/snowlyg/multi"
+)
+
+// newTestClaims returns the valid claims of the admin user, changed by the options.
+func newTestClaims(id uint, opts ...func(*multi.MultiClaims)) *multi.MultiClaims {
+	claims := multi.New(&multi.Multi{
+		Id:            id,
+		Username:      "username",
+		AuthorityIds:  []string{"999"},
+		AuthorityType: multi.AdminAuthority,
+		LoginType:     multi.LoginTypeWeb,
+		AuthType:      multi.AuthPwd,
+		ExpiresAt:     time.Now().Add(multi.RedisSessionTimeoutWeb).Unix(),
+	})
+	for _, opt := range opts {
+		opt(claims)
+	}
+	return claims
+}
@@ -0,0 +1,83 @@
+package iris
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/context"
+	"github.com/snowlyg/multi"
+)
+
+// csrfFailingDriver records the generated token and fails to bind the csrf secret.
+type csrfFailingDriver struct {
+	multi.Authentication
+	token string
+}
+
+func (d *csrfFailingDriver) GenerateToken(claims *multi.MultiClaims) (string, int64, error) {
+	token, expiresAt, err := d.Authentication.GenerateToken(claims)
+	d.token = token
+	return token, expiresAt, err
+}
+
+func (d *csrfFailingDriver) SetCsrfSecret(token string) (string, error) {
+	return "", errors.New("set csrf secret failed")
+}
+
+// issueTokenCookie serves IssueTokenCookie of the driver and returns the response.
+func issueTokenCookie(t *testing.T, driver multi.Authentication) (*httptest.ResponseRecorder, string, error) {
+	var (
+		token string
+		err   error
+	)
+	app := iris.New()
+	app.Logger().SetLevel("disable")
+	app.Get("/", func(ctx *context.Context) {
+		token, err = IssueTokenCookie(ctx, driver, newTestClaims(2))
+	})
+	if err := app.Build(); err != nil {
+		t.Fatalf("build app %v", err)
+	}
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	return w, token, err
+}
+
+func TestIssueTokenCookie(t *testing.T) {
+	driver := multi.NewLocalAuth()
+
+	w, token, err := issueTokenCookie(t, driver)
+	if err != nil {
+		t.Fatalf("issue token cookie %v", err)
+	}
+	defer driver.DelUserTokenCache(token)
+	cookies := map[string]string{}
+	for _, cookie := range w.Result().Cookies() {
+		cookies[cookie.Name] = cookie.Value
+	}
+	secret, _ := driver.GetCsrfSecret(token)
+	if cookies[TokenCookie.Name] != token || cookies[CsrfCookie.Name] != secret || w.Header().Get(CsrfHeader) != secret {
+		t.Errorf("token and csrf cookies want set but get %v", cookies)
+	}
+
+	failing := &csrfFailingDriver{Authentication: driver}
+	w, _, err = issueTokenCookie(t, failing)
+	if err == nil {
+		t.Fatal("issue token cookie want error")
+	}
+	if failing.token == "" {
+		t.Fatal("token of the failed login want generated")
+	}
+	if cookies := w.Header().Values("Set-Cookie"); len(cookies) > 0 {
+		t.Errorf("cookies want not set but get %v", cookies)
+	}
+	if _, err := driver.GetMultiClaims(failing.token); err == nil {
+		t.Error("session of the failed login want deleted")
+	}
+	if _, _, err := issueTokenCookie(t, nil); !errors.Is(err, multi.ErrDriverNil) {
+		t.Errorf("issue token cookie without driver want %v but get %v", multi.ErrDriverNil, err)
+	}
+}
@@ -0,0 +1,97 @@
+package iris
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"time"
+
+	"github.com/kataras/iris/v12/context"
+	"github.com/snowlyg/multi"
+)
+
+const (
+	CsrfHeader    = "X-CSRF-Token" // header which carries the csrf token of unsafe requests
+	CsrfFormField = "_csrf"        // form field which carries the csrf token of unsafe requests
+)
+
+// CsrfCookie carries the csrf token to the client,
+// it is readable by scripts which send it back in the CsrfHeader header.
+var CsrfCookie = CookieOptions{
+	Name:     "multi_csrf",
+	Path:     "/",
+	Secure:   true,
+	SameSite: http.SameSiteLaxMode,
+}
+
+// CsrfErrorHandler is called when the csrf token of an unsafe request is missing or invalid.
+var CsrfErrorHandler = func(ctx *context.Context, err error) {
+	DefaultErrorHandler(ctx, multi.NewVerifyError(multi.ErrCodeForbidden, err))
+}
+
+// IssueCsrfToken binds a new csrf secret to the session of the token by the driver,
+// it is sent in the CsrfCookie cookie and the CsrfHeader header.
+// IssueTokenCookie binds one on login, call it to rotate the csrf token of the session.
+func IssueCsrfToken[T multi.Claims](ctx *context.Context, driver multi.AuthenticationOf[T], token string, loginType int) (string, error) {
+	if driver == nil {
+		return "", multi.ErrDriverNil
+	}
+	secret, err := driver.SetCsrfSecret(token)
+	if err != nil {
+		return "", err
+	}
+	setCsrfToken(ctx, secret, loginType)
+	return secret, nil
+}
+
+// setCsrfToken sends the csrf secret in the CsrfCookie cookie and the CsrfHeader header.
+func setCsrfToken(ctx *context.Context, secret string, loginType int) {
+	expire := multi.GetTokenExpire(loginType)
+	setCookie(ctx, CsrfCookie, secret, int(expire.Seconds()), time.Now().Add(expire), false)
+	ctx.Header(CsrfHeader, secret)
+}
+
+// Csrf returns a middleware which checks the csrf token of unsafe requests,
+// the token is read from the CsrfHeader header or the CsrfFormField field of the form body,
+// never from the query string, and must match the secret bound to the verified session by the driver.
+// It must be registered after Verifier.Verify, on routes authenticated by cookie.
+func Csrf[T multi.Claims](driver multi.AuthenticationOf[T]) context.Handler {
+	return func(ctx *context.Context) {
+		if isSafeMethod(ctx.Method()) {
+			ctx.Next()
+			return
+		}
+		token := GetVerifiedToken(ctx)
+		if token == nil {
+			CsrfErrorHandler(ctx, multi.ErrEmptyToken)
+			return
+		}
+		if driver == nil {
+			CsrfErrorHandler(ctx, multi.ErrDriverNil)
+			return
+		}
+		secret, err := driver.GetCsrfSecret(string(token))
+		if err != nil {
+			CsrfErrorHandler(ctx, err)
+			return
+		}
+		sent := ctx.GetHeader(CsrfHeader)
+		if sent == "" {
+			sent = ctx.PostValue(CsrfFormField)
+		}
+		if sent == "" || subtle.ConstantTimeCompare([]byte(sent), []byte(secret)) != 1 {
+			CsrfErrorHandler(ctx, multi.ErrCsrfInvalid)
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// isSafeMethod reports whether the method does not change state.
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
@@ -42,14 +42,23 @@ func init() {
 
 func auth() iris.Handler {
 	verifier := multi_iris.NewVerifier()
-	verifier.Extractors = []multi_iris.TokenExtractor{multi_iris.FromHeader} // extract token only from Authorization: Bearer $token
+	verifier.Extractors = []multi_iris.TokenExtractor{multi_iris.FromHeader, multi_iris.FromCookie} // extract token from Authorization: Bearer $token or the token cookie
 	return verifier.Verify()
 }
 
+func optionalAuth() iris.Handler {
+	verifier := multi_iris.NewVerifier()
+	verifier.Extractors = []multi_iris.TokenExtractor{multi_iris.FromHeader, multi_iris.FromCookie}
+	return verifier.VerifyOptional()
+}
+
 func main() {
 	app := iris.New()
 
 	app.Get("/", generateToken())
+	app.Get("/cookie", generateTokenCookie)
+	// Allow anonymous clients, the claims are set when the token is valid.
+	app.Get("/public", optionalAuth(), public)
 
 	protectedAPI := app.Party("/protected")
 	// Register the verify middleware to allow access only to authorized clients.
@@ -59,9 +68,20 @@ func main() {
 	protectedAPI.Get("/", protected)
 	// Invalidate the token through server-side, even if it's not expired yet.
 	protectedAPI.Get("/logout", logout)
+	// Allow only the admin, or the tenancy 1 with authority id "999".
+	protectedAPI.Get("/admin", multi_iris.Require(multi_iris.Or(
+		multi_iris.AuthorityType(multi.AdminAuthority),
+		multi_iris.And(multi_iris.Tenancy(1), multi_iris.AnyAuthorityId("999")),
+	)), protected)
+	// Unsafe requests authenticated by the token cookie must send the csrf token in X-CSRF-Token.
+	protectedAPI.Post("/", multi_iris.Csrf(multi.AuthDriver), protected)
 
 	// http://localhost:8080
+	// http://localhost:8080/cookie (or the token cookie)
+	// http://localhost:8080/public (with or without the token)
 	// http://localhost:8080/protected (or Authorization: Bearer $token)
+	// http://localhost:8080/protected/admin
+	// POST http://localhost:8080/protected (X-CSRF-Token: $csrf)
 	// http://localhost:8080/protected/logout
 	// http://localhost:8080/protected (401)
 	app.Listen(":8080")
@@ -92,6 +112,37 @@ func generateToken() iris.Handler {
 	}
 }
 
+func generateTokenCookie(ctx iris.Context) {
+	claims := &multi.MultiClaims{
+		Id:            "1",
+		Username:      "your name",
+		AuthorityId:   "your authority id",
+		TenancyId:     1,
+		TenancyName:   "your tenancy name",
+		AuthorityType: multi.AdminAuthority,
+		LoginType:     multi.LoginTypeWeb,
+		AuthType:      multi.AuthPwd,
+		CreationDate:  time.Now().Local().Unix(),
+		ExpiresAt:     time.Now().Local().Add(multi.RedisSessionTimeoutWeb).Unix(),
+	}
+
+	// the token is sent as an HttpOnly, Secure, SameSite cookie
+	if _, err := multi_iris.IssueTokenCookie(ctx, multi.AuthDriver, claims); err != nil {
+		ctx.StopWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	ctx.WriteString("token cookie issued")
+}
+
+func public(ctx iris.Context) {
+	if multi_iris.IsAnonymous(ctx) {
+		ctx.WriteString("hello guest")
+		return
+	}
+	protected(ctx)
+}
+
 func protected(ctx iris.Context) {
 	claims := multi_iris.Get(ctx)
 	ctx.Writef("claims=%+v\n", claims)
@@ -108,5 +159,6 @@ func logout(ctx iris.Context) {
 		ctx.WriteString(err.Error())
 		return
 	}
+	multi_iris.ClearTokenCookie(ctx)
 	ctx.Writef("token invalidated, a new token is required to access the protected API")
 }
@@ -1,9 +1,13 @@
 package iris
 
 import (
+	"bytes"
+	"encoding/json"
+	"io"
 	"strings"
 
 	"github.com/kataras/iris/v12/context"
+	"github.com/snowlyg/multi"
 )
 
 // TokenExtractor is a function that takes a context as input and returns
@@ -15,57 +19,85 @@ type TokenExtractor func(*context.Context) string
 // It reads the token from the Authorization request header of form:
 // Authorization: "Bearer {token}".
 func FromHeader(ctx *context.Context) string {
-	authHeader := ctx.GetHeader("Authorization")
-	if authHeader == "" {
-		return ""
-	}
+	return FromHeaderName("Authorization", "Bearer")(ctx)
+}
 
-	// pure check: authorization header format must be Bearer {token}
-	authHeaderParts := strings.Split(authHeader, " ")
-	if len(authHeaderParts) != 2 || strings.ToLower(authHeaderParts[0]) != "bearer" {
-		return ""
+// FromHeaderName is a token extractor.
+// It reads the token from the request header of the given name and form:
+// name: "{scheme} {token}", the scheme is case-insensitive and may be empty
+// to read the whole header value as the token.
+func FromHeaderName(name, scheme string) TokenExtractor {
+	return func(ctx *context.Context) string {
+		return multi.ParseHeaderToken(ctx.GetHeader(name), scheme)
 	}
+}
 
-	return authHeaderParts[1]
+// FromWebSocketProtocol is a token extractor.
+// It reads the token from the Sec-WebSocket-Protocol request header of form:
+// Sec-WebSocket-Protocol: "bearer, {token}",
+// as browsers can not set the Authorization header on websocket upgrades.
+// The upgrader should respond with the WebSocketProtocol subprotocol.
+func FromWebSocketProtocol(ctx *context.Context) string {
+	protocols := strings.Split(ctx.GetHeader("Sec-WebSocket-Protocol"), ",")
+	for i := 0; i < len(protocols)-1; i++ {
+		if strings.EqualFold(strings.TrimSpace(protocols[i]), WebSocketProtocol) {
+			return strings.TrimSpace(protocols[i+1])
+		}
+	}
+	return ""
 }
 
 // FromQuery is a token extractor.
 // It reads the token from the "token" url query parameter.
 func FromQuery(ctx *context.Context) string {
-	return ctx.URLParam("token")
+	return ctx.URLParamTrim("token")
+}
+
+// FromForm is a token extractor.
+// It reads the token from the given field of the url-encoded or multipart form body,
+// the parsed form stays available to the handler.
+func FromForm(field string) TokenExtractor {
+	return func(ctx *context.Context) string {
+		return ctx.PostValueTrim(field)
+	}
+}
+
+// FromParam is a token extractor.
+// It reads the token from the given path parameter, e.g. "/files/{token}".
+func FromParam(name string) TokenExtractor {
+	return func(ctx *context.Context) string {
+		return strings.TrimSpace(ctx.Params().Get(name))
+	}
 }
 
 // FromJSON is a token extractor.
 // Reads a json request body and extracts the json based on the given field.
 // The request content-type should contain the: application/json header value, otherwise
-// this method will not try to read and consume the body.
+// this method will not try to read the body.
+// The body is restored after reading, so the handler can still read it.
 func FromJSON(jsonKey string) TokenExtractor {
 	return func(ctx *context.Context) string {
-		if ctx.GetContentTypeRequested() != context.ContentJSONHeaderValue {
-			return ""
-		}
-
-		var m context.Map
-		ctx.RecordRequestBody(true)
-		defer ctx.RecordRequestBody(false)
-		if err := ctx.ReadJSON(&m); err != nil {
+		req := ctx.Request()
+		if ctx.GetContentTypeRequested() != context.ContentJSONHeaderValue || req.Body == nil {
 			return ""
 		}
 
-		if m == nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		if err != nil {
 			return ""
 		}
 
-		v, ok := m[jsonKey]
-		if !ok {
+		var m context.Map
+		if err := json.Unmarshal(body, &m); err != nil {
 			return ""
 		}
 
-		tok, ok := v.(string)
+		tok, ok := m[jsonKey].(string)
 		if !ok {
 			return ""
 		}
 
-		return tok
+		return strings.TrimSpace(tok)
 	}
 }
@@ -0,0 +1,102 @@
+package iris
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/context"
+	"github.com/snowlyg/multi"
+)
+
+func TestGuards(t *testing.T) {
+	driver := multi.NewLocalAuth()
+	admin, _, err := driver.GenerateToken(newTestClaims(4))
+	if err != nil {
+		t.Fatalf("generate token %v", err)
+	}
+	defer driver.DelUserTokenCache(admin)
+	tenancy, _, err := driver.GenerateToken(newTestClaims(5, func(c *multi.MultiClaims) {
+		c.AuthorityType = multi.TenancyAuthority
+		c.AuthorityId = "301"
+		c.TenancyId = 7
+	}))
+	if err != nil {
+		t.Fatalf("generate token %v", err)
+	}
+	defer driver.DelUserTokenCache(tenancy)
+
+	app := iris.New()
+	app.Logger().SetLevel("disable")
+	app.Use(NewVerifierOf[*multi.MultiClaims](driver).Verify())
+	routes := map[string]context.Handler{
+		"/admin":          RequireAuthorityType(multi.AdminAuthority),
+		"/authority":      RequireAnyAuthorityId("301", "302"),
+		"/tenancy":        RequireTenancy(),
+		"/tenancy/7":      RequireTenancy(7),
+		"/tenancy/8":      RequireTenancy(8),
+		"/admin-tenancy":  Require(AuthorityType(multi.AdminAuthority), Tenancy()),
+		"/admin-or-7":     Require(Or(AuthorityType(multi.AdminAuthority), Tenancy(7))),
+		"/general-or-301": Require(Or(AuthorityType(multi.GeneralAuthority), AnyAuthorityId("301"))),
+	}
+	for path, guard := range routes {
+		app.Get(path, guard, func(ctx *context.Context) {
+			ctx.StatusCode(http.StatusOK)
+		})
+	}
+	if err := app.Build(); err != nil {
+		t.Fatalf("build app %v", err)
+	}
+
+	tests := []struct {
+		path   string
+		token  string
+		status int
+	}{
+		{path: "/admin", token: admin, status: http.StatusOK},
+		{path: "/admin", token: tenancy, status: http.StatusForbidden},
+		{path: "/authority", token: tenancy, status: http.StatusOK},
+		{path: "/authority", token: admin, status: http.StatusForbidden},
+		{path: "/tenancy", token: tenancy, status: http.StatusOK},
+		{path: "/tenancy", token: admin, status: http.StatusForbidden},
+		{path: "/tenancy/7", token: tenancy, status: http.StatusOK},
+		{path: "/tenancy/8", token: tenancy, status: http.StatusForbidden},
+		{path: "/admin-tenancy", token: admin, status: http.StatusForbidden},
+		{path: "/admin-tenancy", token: tenancy, status: http.StatusForbidden},
+		{path: "/admin-or-7", token: admin, status: http.StatusOK},
+		{path: "/admin-or-7", token: tenancy, status: http.StatusOK},
+		{path: "/general-or-301", token: tenancy, status: http.StatusOK},
+		{path: "/general-or-301", token: admin, status: http.StatusForbidden},
+	}
+	for _, test := range tests {
+		name := test.path[1:] + " of admin"
+		if test.token == tenancy {
+			name = test.path[1:] + " of tenancy"
+		}
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, test.path, nil)
+			req.Header.Set("Authorization", "Bearer "+test.token)
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, req)
+			if w.Code != test.status {
+				t.Fatalf("status want %d but get %d", test.status, w.Code)
+			}
+			if test.status != http.StatusForbidden {
+				return
+			}
+			if contentType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type")); contentType != multi.ProblemContentType {
+				t.Errorf("content type want %s but get %s", multi.ProblemContentType, contentType)
+			}
+			var problem multi.Problem
+			if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("unmarshal problem %v", err)
+			}
+			if problem.Code != multi.ErrCodeForbidden || problem.Status != http.StatusForbidden {
+				t.Errorf("problem want %s %d but get %s %d", multi.ErrCodeForbidden, http.StatusForbidden, problem.Code, problem.Status)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package iris
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/context"
+	"github.com/snowlyg/multi"
+)
+
+func TestVerifyOptional(t *testing.T) {
+	driver := multi.NewLocalAuth()
+	token, _, err := driver.GenerateToken(newTestClaims(3))
+	if err != nil {
+		t.Fatalf("generate token %v", err)
+	}
+	defer driver.DelUserTokenCache(token)
+
+	var anonymous bool
+	var userId uint
+	app := iris.New()
+	app.Logger().SetLevel("disable")
+	app.Use(NewVerifierOf[*multi.MultiClaims](driver).VerifyOptional())
+	app.Get("/", func(ctx *context.Context) {
+		anonymous, userId = IsAnonymous(ctx), GetUserId(ctx)
+		ctx.StatusCode(http.StatusOK)
+	})
+	if err := app.Build(); err != nil {
+		t.Fatalf("build app %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		token     string
+		status    int
+		anonymous bool
+		userId    uint
+	}{
+		{name: "no token", status: http.StatusOK, anonymous: true},
+		{name: "invalid token", token: "invalid", status: http.StatusUnauthorized},
+		{name: "valid token", token: token, status: http.StatusOK, userId: 3},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			anonymous, userId = false, 0
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.token != "" {
+				req.Header.Set("Authorization", "Bearer "+test.token)
+			}
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, req)
+			if w.Code != test.status {
+				t.Fatalf("status want %d but get %d", test.status, w.Code)
+			}
+			if test.status != http.StatusOK {
+				if w.Header().Get("WWW-Authenticate") == "" {
+					t.Error("rejected request want the WWW-Authenticate header")
+				}
+				return
+			}
+			if anonymous != test.anonymous {
+				t.Errorf("is anonymous want %t but get %t", test.anonymous, anonymous)
+			}
+			if userId != test.userId {
+				t.Errorf("user id want %d but get %d", test.userId, userId)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package iris
+
+import (
+	"github.com/kataras/iris/v12/context"
+	"github.com/snowlyg/multi"
+)
+
+// WebSocketProtocol is the subprotocol which carries the token, see FromWebSocketProtocol.
+const WebSocketProtocol = "bearer"
+
+// OnRevoke calls fn once when the session of the verified token is revoked by the driver of the verifier,
+// e.g. to close the websocket connection upgraded from the request.
+// The returned cancel func should be called when the connection is closed.
+func (v *VerifierOf[T]) OnRevoke(ctx *context.Context, fn func()) (cancel func()) {
+	token := GetVerifiedToken(ctx)
+	if token == nil {
+		return func() {}
+	}
+	return multi.WatchRevoked(multi.DriverOf(v.Driver), string(token), fn)
+}
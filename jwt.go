@@ -1,22 +1,45 @@
 package multi
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"time"
 
 	"github.com/golang-jwt/jwt"
+	"github.com/patrickmn/go-cache"
 )
 
 var hmacSampleSecret = []byte("updPA0L2uQ56LwHZoyUX")
 
 // JwtAuth
-type JwtAuth struct {
+type JwtAuth = JwtAuthOf[*MultiClaims]
+
+// JwtAuthOf
+// Revoked keeps the revocation time of users in memory, tokens created before it are invalid.
+// The revocations are per process only: they are not shared by the instances, are lost on restart
+// and do not notify the RevokeWatcher, block the tokens by a Redis Blocklist to revoke them everywhere.
+// GetMultiClaims rejects the expired claims by the Clock, Leeway tolerates the clock skew of the issuers.
+// Events receives the session lifecycle events, if it is set.
+type JwtAuthOf[T Claims] struct {
 	HmacSecret []byte
+	Revoked    *cache.Cache
+	Clock      Clock
+	Leeway     time.Duration
+	Events     EventDispatcher
 }
 
 // NewJwtAuth
 func NewJwtAuth(hmacSecret []byte) *JwtAuth {
-	ja := &JwtAuth{
+	return NewJwtAuthOf[*MultiClaims](hmacSecret)
+}
+
+// NewJwtAuthOf
+func NewJwtAuthOf[T Claims](hmacSecret []byte) *JwtAuthOf[T] {
+	ja := &JwtAuthOf[T]{
 		HmacSecret: hmacSecret,
+		Revoked:    cache.New(cache.NoExpiration, 24*time.Minute),
 	}
 	if ja.HmacSecret == nil {
 		ja.HmacSecret = hmacSampleSecret
@@ -25,7 +48,9 @@ func NewJwtAuth(hmacSecret []byte) *JwtAuth {
 }
 
 // GenerateToken
-func (ra *JwtAuth) GenerateToken(claims *MultiClaims) (string, int64, error) {
+func (ra *JwtAuthOf[T]) GenerateToken(claims T) (string, int64, error) {
+	// the creation date is compared with the revocations which are timed by the clock of the driver.
+	claims.Standard().CreationDate = clockOf(ra.Clock).Now().Unix()
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
 	// Sign and get the complete encoded token as a string using the secret
@@ -33,18 +58,55 @@ func (ra *JwtAuth) GenerateToken(claims *MultiClaims) (string, int64, error) {
 	if err != nil {
 		return "", 0, err
 	}
+	dispatch(ra.Events, ra.Clock, Event{Type: EventLogin, Token: ra.eventToken(tokenString), Claims: claims.Standard()})
 	return tokenString, 0, nil
 }
 
 // GetTokenByClaims
-func (ra *JwtAuth) GetTokenByClaims(cla *MultiClaims) (string, error) {
+func (ra *JwtAuthOf[T]) GetTokenByClaims(cla T) (string, error) {
 	return "", ErrForJwt
 }
 
 // GetMultiClaims
-func (ra *JwtAuth) GetMultiClaims(tokenString string) (*MultiClaims, error) {
-	mc := &MultiClaims{}
-	token, err := jwt.ParseWithClaims(tokenString, mc, func(token *jwt.Token) (interface{}, error) {
+func (ra *JwtAuthOf[T]) GetMultiClaims(tokenString string) (T, error) {
+	claims, err := ra.findMultiClaims(tokenString)
+	if err != nil {
+		ra.verifyFailed(tokenString, RequestInfo{}, err)
+	}
+	return claims, err
+}
+
+// verifyFailed dispatches the EventVerifyFailed event of the token.
+func (ra *JwtAuthOf[T]) verifyFailed(token string, req RequestInfo, err error) {
+	dispatch(ra.Events, ra.Clock, Event{Type: EventVerifyFailed, Token: ra.eventToken(token), Request: req, Err: err})
+}
+
+// eventToken returns the sha256 hex of the token, the events never carry the token which is a credential.
+func (ra *JwtAuthOf[T]) eventToken(token string) string {
+	if token == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// clock
+func (ra *JwtAuthOf[T]) clock() Clock {
+	return clockOf(ra.Clock)
+}
+
+// leeway
+func (ra *JwtAuthOf[T]) leeway() time.Duration {
+	return ra.Leeway
+}
+
+// findMultiClaims returns the claims of the token validated by the clock of the driver.
+func (ra *JwtAuthOf[T]) findMultiClaims(tokenString string) (T, error) {
+	var empty T
+	mc := newClaims[T]()
+	// the claims are validated below by the clock of the driver.
+	parser := &jwt.Parser{SkipClaimsValidation: true}
+	token, err := parser.ParseWithClaims(tokenString, mc, func(token *jwt.Token) (interface{}, error) {
 		// Don't forget to validate the alg is what you expect:
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("不支持的签名方法: %v", token.Header["alg"])
@@ -52,45 +114,110 @@ func (ra *JwtAuth) GetMultiClaims(tokenString string) (*MultiClaims, error) {
 		return ra.HmacSecret, nil
 	})
 	if err != nil {
-		return nil, err
+		return empty, err
 	}
 
-	if _, ok := token.Claims.(*MultiClaims); ok && token.Valid {
-		return mc, nil
-	} else {
-		return nil, ErrTokenInvalid
+	if _, ok := token.Claims.(T); !ok || !token.Valid {
+		return empty, ErrTokenInvalid
 	}
+	if err := Leeway(ra.Leeway).ValidateClaims(nil, mc.Standard(), validAt(mc, ra.clock().Now())); err != nil {
+		return empty, err
+	}
+	if ra.isRevoked(mc.Standard()) {
+		return empty, ErrTokenInvalid
+	}
+	return mc, nil
 }
 
 // SetUserTokenMaxCount
-func (ra *JwtAuth) SetUserTokenMaxCount(tokenMaxCount int64) error {
+func (ra *JwtAuthOf[T]) SetUserTokenMaxCount(tokenMaxCount int64) error {
 	return ErrForJwt
 }
 
 // UpdateUserTokenCacheExpire
-func (ra *JwtAuth) UpdateUserTokenCacheExpire(token string) error {
+func (ra *JwtAuthOf[T]) UpdateUserTokenCacheExpire(token string) error {
 	return ErrForJwt
 }
 
 // DelUserTokenCache
-func (ra *JwtAuth) DelUserTokenCache(token string) error {
+func (ra *JwtAuthOf[T]) DelUserTokenCache(token string) error {
 	return ErrForJwt
 }
 
 // CleanUserTokenCache
-func (ra *JwtAuth) CleanUserTokenCache(authorityType int, userId string) error {
+func (ra *JwtAuthOf[T]) CleanUserTokenCache(authorityType int, userId string) error {
 	return ErrForJwt
 }
 
+// CleanAllUserTokenCache revokes every token of the user created before the current second,
+// so the token issued right after it, e.g. on changing the password, stays valid.
+// The revocation is kept as long as the longest session lasts.
+func (ra *JwtAuthOf[T]) CleanAllUserTokenCache(userId string) error {
+	ra.Revoked.Set(getUserIdPrefixKey(userId), clockOf(ra.Clock).Now().Unix(), maxTokenExpire())
+	dispatch(ra.Events, ra.Clock, Event{Type: EventRevoke, Claims: &MultiClaims{Id: userId}, Reason: RevokeReasonCleaned})
+	return nil
+}
+
+// isRevoked
+func (ra *JwtAuthOf[T]) isRevoked(cla *MultiClaims) bool {
+	revokedAt, found := ra.Revoked.Get(getUserIdPrefixKey(cla.Id))
+	if !found {
+		return false
+	}
+	// the creation date has a second resolution, the tokens of the revoking second are kept.
+	return cla.CreationDate < revokedAt.(int64)
+}
+
+// CleanTenancyTokenCache
+func (ra *JwtAuthOf[T]) CleanTenancyTokenCache(tenancyId uint) error {
+	return ErrForJwt
+}
+
+// GetTenancyTokens
+func (ra *JwtAuthOf[T]) GetTenancyTokens(tenancyId uint) (map[string]T, error) {
+	return nil, ErrForJwt
+}
+
+// GetTenancyTokenCount
+func (ra *JwtAuthOf[T]) GetTenancyTokenCount(tenancyId uint) (int64, error) {
+	return 0, ErrForJwt
+}
+
+// CleanAuthorityTokenCache
+func (ra *JwtAuthOf[T]) CleanAuthorityTokenCache(authorityId string) error {
+	return ErrForJwt
+}
+
+// GetAuthorityTokens
+func (ra *JwtAuthOf[T]) GetAuthorityTokens(authorityId string) (map[string]T, error) {
+	return nil, ErrForJwt
+}
+
+// SetCsrfSecret returns the csrf secret of the token,
+// the jwt driver derives it from the token as it keeps no session.
+func (ra *JwtAuthOf[T]) SetCsrfSecret(token string) (string, error) {
+	return ra.GetCsrfSecret(token)
+}
+
+// GetCsrfSecret returns the hmac of the token as the csrf secret.
+func (ra *JwtAuthOf[T]) GetCsrfSecret(token string) (string, error) {
+	if _, err := ra.GetMultiClaims(token); err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, ra.HmacSecret)
+	mac.Write([]byte(GtSessionCsrfPrefix + token))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
 // IsRole
-func (ra *JwtAuth) IsRole(token string, authorityType int) (bool, error) {
+func (ra *JwtAuthOf[T]) IsRole(token string, authorityType int) (bool, error) {
 	rcc, err := ra.GetMultiClaims(token)
 	if err != nil {
 		return false, fmt.Errorf("get User's infomation return error: %w", err)
 	}
-	return rcc.AuthorityType == authorityType, nil
+	return rcc.Standard().AuthorityType == authorityType, nil
 }
 
 // Close
-func (ra *JwtAuth) Close() {
+func (ra *JwtAuthOf[T]) Close() {
 }
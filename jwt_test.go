@@ -1,6 +1,7 @@
 package multi
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -137,3 +138,97 @@ func TestJwtGetTokenByClaims(t *testing.T) {
 	})
 
 }
+
+func TestJwtAuthOfCustomClaims(t *testing.T) {
+	auth := NewJwtAuthOf[*avatarClaims](nil)
+	claims := newAvatarTestClaims(8457586)
+	t.Run("test custom claims", func(t *testing.T) {
+		token, _, err := auth.GenerateToken(claims)
+		if err != nil {
+			t.Fatalf("generate token %v", err)
+		}
+		cc, err := auth.GetMultiClaims(token)
+		if err != nil {
+			t.Fatalf("get custom claims %v", err)
+		}
+		if cc.Avatar != claims.Avatar {
+			t.Errorf("get custom avatar want %v but get %v", claims.Avatar, cc.Avatar)
+		}
+	})
+}
+
+func TestJwtCleanAllUserTokenCache(t *testing.T) {
+	auth := NewJwtAuth(nil)
+	token, _, err := auth.GenerateToken(newTestClaims(995))
+	if err != nil {
+		t.Fatalf("generate token %v", err)
+	}
+	t.Run("test clean all user token cache", func(t *testing.T) {
+		// the tokens of the revoking second are kept, so revoke a second later.
+		auth.Clock = NewFakeClock(time.Now().Add(time.Second))
+		if err := auth.CleanAllUserTokenCache("995"); err != nil {
+			t.Fatalf("clean all user token cache %v", err)
+		}
+		if _, err := auth.GetMultiClaims(token); !errors.Is(err, ErrTokenInvalid) {
+			t.Errorf("get custom claims want %v but get %v", ErrTokenInvalid, err)
+		}
+	})
+}
+
+func TestJwtCsrfSecret(t *testing.T) {
+	auth := NewJwtAuth(nil)
+	token, _, err := auth.GenerateToken(newTestClaims(996))
+	if err != nil {
+		t.Fatalf("generate token %v", err)
+	}
+	t.Run("test csrf secret", func(t *testing.T) {
+		secret, err := auth.SetCsrfSecret(token)
+		if err != nil {
+			t.Fatalf("set csrf secret %v", err)
+		}
+		got, err := auth.GetCsrfSecret(token)
+		if err != nil {
+			t.Fatalf("get csrf secret %v", err)
+		}
+		if got != secret {
+			t.Errorf("get csrf secret want %s but get %s", secret, got)
+		}
+		if other, _ := NewJwtAuth([]byte("other secret")).GetCsrfSecret(token); other == secret {
+			t.Errorf("csrf secret of other hmac secret should not be %s", secret)
+		}
+	})
+}
+
+func TestJwtValidAggregated(t *testing.T) {
+	auth := NewJwtAuth(nil)
+	cla := newTestClaims(997)
+	cla.ExpiresAt = time.Now().Local().Add(-time.Hour).Unix()
+	token, _, err := auth.GenerateToken(cla)
+	if err != nil {
+		t.Fatalf("generate token %v", err)
+	}
+	if _, err := auth.GetMultiClaims(token); !errors.Is(err, ErrClaimsExpired) {
+		t.Errorf("get multi claims want %v but get %v", ErrClaimsExpired, err)
+	}
+}
+
+func TestJwtLeeway(t *testing.T) {
+	auth := NewJwtAuth(nil)
+	cla := newTestClaims(996)
+	cla.ExpiresAt = time.Now().Add(-2 * time.Second).Unix()
+	token, _, err := auth.GenerateToken(cla)
+	if err != nil {
+		t.Fatalf("generate token %v", err)
+	}
+	auth.Leeway = 5 * time.Second
+	if _, err := auth.GetMultiClaims(token); err != nil {
+		t.Errorf("get multi claims within the leeway want nil but get %v", err)
+	}
+	if _, err := VerifyRequest[*MultiClaims](auth, []byte(token), RequestInfo{}); err != nil {
+		t.Errorf("verify request within the leeway want nil but get %v", err)
+	}
+	auth.Leeway = time.Second
+	if _, err := auth.GetMultiClaims(token); !errors.Is(err, ErrClaimsExpired) {
+		t.Errorf("get multi claims out of the leeway want %v but get %v", ErrClaimsExpired, err)
+	}
+}
@@ -0,0 +1,85 @@
+package multi
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrClaimsUndecryptable = internal(errors.New("CLAIMS CAN NOT BE DECRYPTED"))
+
+// KeyRing encrypts the claims at rest with AES-GCM.
+// The claims are sealed by the current key and opened by the key they were sealed with,
+// so to rotate the key, add a new one as current and keep the retired ones
+// until the sessions sealed by them expire.
+type KeyRing struct {
+	current string
+	aeads   map[string]cipher.AEAD
+}
+
+// NewKeyRing returns the key ring of the keys by id, current is the id of the key which seals the claims.
+// The keys are 16, 24 or 32 bytes to select AES-128, AES-192 or AES-256,
+// the ids are stored with the sealed claims and must not contain ':'.
+func NewKeyRing(current string, keys map[string][]byte) (*KeyRing, error) {
+	if _, ok := keys[current]; !ok {
+		return nil, fmt.Errorf("mutil: key ring has no current key %q", current)
+	}
+	kr := &KeyRing{current: current, aeads: make(map[string]cipher.AEAD, len(keys))}
+	for id, key := range keys {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("mutil: key ring key id %q is invalid", id)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("mutil: key ring key %q %w", id, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("mutil: key ring key %q %w", id, err)
+		}
+		kr.aeads[id] = aead
+	}
+	return kr, nil
+}
+
+// Seal encrypts the plaintext by the current key, the additional data is authenticated but not stored,
+// it must be passed to Open again.
+func (kr *KeyRing) Seal(plaintext, additionalData []byte) (string, error) {
+	aead := kr.aeads[kr.current]
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("mutil: seal claims %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, additionalData)
+	return kr.current + ":" + string(Base64Encode(sealed)), nil
+}
+
+// Open decrypts the sealed text by the key it was sealed with.
+func (kr *KeyRing) Open(sealed string, additionalData []byte) ([]byte, error) {
+	id, text, ok := strings.Cut(sealed, ":")
+	if !ok {
+		return nil, fmt.Errorf("%w: no key id", ErrClaimsUndecryptable)
+	}
+	aead, ok := kr.aeads[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown key %q", ErrClaimsUndecryptable, id)
+	}
+	buf, err := Base64Decode([]byte(text))
+	if err != nil || len(buf) < aead.NonceSize() {
+		return nil, fmt.Errorf("%w: bad encoding", ErrClaimsUndecryptable)
+	}
+	plaintext, err := aead.Open(nil, buf[:aead.NonceSize()], buf[aead.NonceSize():], additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrClaimsUndecryptable, err)
+	}
+	return plaintext, nil
+}
+
+// NeedsRotation reports whether the sealed text was sealed by a retired key.
+func (kr *KeyRing) NeedsRotation(sealed string) bool {
+	id, _, _ := strings.Cut(sealed, ":")
+	return id != kr.current
+}
@@ -0,0 +1,130 @@
+package multi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+)
+
+var (
+	ringKey1 = bytes.Repeat([]byte("1"), 32)
+	ringKey2 = bytes.Repeat([]byte("2"), 16)
+)
+
+func TestKeyRing(t *testing.T) {
+	kr, err := NewKeyRing("k1", map[string][]byte{"k1": ringKey1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := kr.Seal([]byte("username"), []byte("GST:1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(sealed, "k1:") || strings.Contains(sealed, "username") {
+		t.Errorf("sealed want key id prefix and no plaintext but get %s", sealed)
+	}
+	if plaintext, err := kr.Open(sealed, []byte("GST:1")); err != nil || string(plaintext) != "username" {
+		t.Errorf("open want username but get %s %v", plaintext, err)
+	}
+	if _, err := kr.Open(sealed, []byte("GST:2")); !errors.Is(err, ErrClaimsUndecryptable) {
+		t.Errorf("open with other additional data want %v but get %v", ErrClaimsUndecryptable, err)
+	}
+	if _, err := kr.Open("k9:"+sealed[3:], []byte("GST:1")); !errors.Is(err, ErrClaimsUndecryptable) {
+		t.Errorf("open with unknown key want %v but get %v", ErrClaimsUndecryptable, err)
+	}
+
+	rotated, err := NewKeyRing("k2", map[string][]byte{"k1": ringKey1, "k2": ringKey2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rotated.NeedsRotation(sealed) {
+		t.Error("sealed by retired key want rotation")
+	}
+	if plaintext, err := rotated.Open(sealed, []byte("GST:1")); err != nil || string(plaintext) != "username" {
+		t.Errorf("open by retired key want username but get %s %v", plaintext, err)
+	}
+
+	for name, keys := range map[string]map[string][]byte{
+		"no current": {"k2": ringKey2},
+		"bad size":   {"k1": []byte("short")},
+		"bad id":     {"k1": ringKey1, "k:2": ringKey2},
+	} {
+		if _, err := NewKeyRing("k1", keys); err == nil {
+			t.Errorf("new key ring of %s want error", name)
+		}
+	}
+}
+
+func TestRedisSealedClaims(t *testing.T) {
+	client := redis.NewUniversalClient(options)
+	redisAuth, err := NewRedisAuth(client)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer redisAuth.CleanUserTokenCache(redisClaims.AuthorityType, redisClaims.Id)
+	redisAuth.KeyRing, _ = NewKeyRing("k1", map[string][]byte{"k1": ringKey1})
+
+	token, _, err := redisAuth.GenerateToken(redisClaims)
+	if err != nil {
+		t.Fatalf("generate token %v", err)
+	}
+	values, _ := client.HGetAll(context.Background(), GtSessionTokenPrefix+token).Result()
+	if len(values) != 1 || !strings.HasPrefix(values[sealedClaimsField], "k1:") {
+		t.Errorf("session want only sealed claims but get %v", values)
+	}
+	cla, err := redisAuth.GetMultiClaims(token)
+	if err != nil {
+		t.Fatalf("get custom claims %v", err)
+	}
+	if cla.Username != redisClaims.Username || cla.TenancyName != redisClaims.TenancyName {
+		t.Errorf("get custom claims want %+v but get %+v", redisClaims, cla)
+	}
+
+	plainAuth, _ := NewRedisAuth(client)
+	if _, err := plainAuth.GetMultiClaims(token); !errors.Is(err, ErrClaimsUndecryptable) {
+		t.Errorf("get sealed claims without key ring want %v but get %v", ErrClaimsUndecryptable, err)
+	}
+
+	redisAuth.KeyRing, _ = NewKeyRing("k2", map[string][]byte{"k1": ringKey1, "k2": ringKey2})
+	if _, err := redisAuth.GetMultiClaims(token); err != nil {
+		t.Errorf("get claims sealed by retired key %v", err)
+	}
+	if err := redisAuth.UpdateUserTokenCacheExpire(token); err != nil {
+		t.Fatalf("update user token cache expire %v", err)
+	}
+	if sealed, _ := client.HGet(context.Background(), GtSessionTokenPrefix+token, sealedClaimsField).Result(); !strings.HasPrefix(sealed, "k2:") {
+		t.Errorf("claims want sealed again by k2 but get %s", sealed)
+	}
+	if ttl, _ := client.TTL(context.Background(), GtSessionTokenPrefix+token).Result(); ttl <= 0 {
+		t.Errorf("session want expire but get ttl %v", ttl)
+	}
+}
+
+func TestRedisSealPlaintextClaims(t *testing.T) {
+	client := redis.NewUniversalClient(options)
+	redisAuth, err := NewRedisAuth(client)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer redisAuth.CleanUserTokenCache(redisClaims.AuthorityType, redisClaims.Id)
+	token, _, err := redisAuth.GenerateToken(redisClaims)
+	if err != nil {
+		t.Fatalf("generate token %v", err)
+	}
+
+	redisAuth.KeyRing, _ = NewKeyRing("k1", map[string][]byte{"k1": ringKey1})
+	if _, err := redisAuth.GetMultiClaims(token); err != nil {
+		t.Errorf("get plaintext claims %v", err)
+	}
+	if err := redisAuth.UpdateUserTokenCacheExpire(token); err != nil {
+		t.Fatalf("update user token cache expire %v", err)
+	}
+	values, _ := client.HGetAll(context.Background(), GtSessionTokenPrefix+token).Result()
+	if _, ok := values["username"]; ok || values[sealedClaimsField] == "" {
+		t.Errorf("plaintext session want sealed but get %v", values)
+	}
+}
@@ -10,129 +10,282 @@ import (
 
 type tokens []string
 
+// remove returns the tokens without the given token.
+func (ts tokens) remove(token string) tokens {
+	rs := make(tokens, 0, len(ts))
+	for _, t := range ts {
+		if t != token {
+			rs = append(rs, t)
+		}
+	}
+	return rs
+}
+
 var localCache *cache.Cache
 
-type LocalAuth struct {
-	Cache *cache.Cache
+type LocalAuth = LocalAuthOf[*MultiClaims]
+
+// LocalAuthOf
+// Clock decides the session deadlines, the cache itself expires by the system time.
+// Generator generates the tokens, DefaultTokenGenerator is used if it is nil.
+// Hasher hashes the tokens at rest, the tokens are stored verbatim if it is nil.
+// Events receives the session lifecycle events, if it is set.
+type LocalAuthOf[T Claims] struct {
+	Cache     *cache.Cache
+	Clock     Clock
+	Generator TokenGenerator
+	Hasher    *TokenHasher
+	Events    EventDispatcher
 }
 
 func NewLocalAuth() *LocalAuth {
+	return NewLocalAuthOf[*MultiClaims]()
+}
+
+func NewLocalAuthOf[T Claims]() *LocalAuthOf[T] {
 	if localCache == nil {
 		localCache = cache.New(4*time.Hour, 24*time.Minute)
 	}
-	return &LocalAuth{
+	return &LocalAuthOf[T]{
 		Cache: localCache,
 	}
 }
 
 // GenerateToken
-func (la *LocalAuth) GenerateToken(claims *MultiClaims) (string, int64, error) {
-	if la.isUserTokenOver(claims.AuthorityType, claims.Id) {
-		return "", 0, errors.New("over login device limit")
+func (la *LocalAuthOf[T]) GenerateToken(claims T) (string, int64, error) {
+	std := claims.Standard()
+	if la.isUserTokenOver(std.AuthorityType, std.Id) {
+		err := errors.New("over login device limit")
+		dispatch(la.Events, la.Clock, Event{Type: EventLimitExceeded, Claims: std, Err: err})
+		return "", 0, err
 	}
-	token, err := GetToken()
+	token, err := generatorOf(la.Generator).Generate()
 	if err != nil {
 		return "", 0, err
 	}
-	err = la.toCache(token, claims)
+	id := hashToken(la.Hasher, token)
+	err = la.toCache(id, claims)
 	if err != nil {
 		return "", 0, err
 	}
-	if err = la.syncUserTokenCache(token); err != nil {
+	if err = la.syncUserTokenCache(id); err != nil {
 		return "", 0, err
 	}
 
-	return token, int64(claims.ExpiresAt), err
+	dispatch(la.Events, la.Clock, Event{Type: EventLogin, Token: id, Claims: std})
+	return token, int64(std.ExpiresAt), err
 }
 
-func (la *LocalAuth) toCache(token string, rcc *MultiClaims) error {
+func (la *LocalAuthOf[T]) toCache(token string, rcc T) error {
 	sKey := GtSessionTokenPrefix + token
-	la.Cache.Set(sKey, rcc, getTokenExpire(rcc.LoginType))
+	expire := GetTokenExpire(rcc.Standard().LoginType)
+	la.Cache.Set(sKey, rcc, expire)
+	la.setDeadline(token, expire)
 	return nil
 }
 
-func (la *LocalAuth) syncUserTokenCache(token string) error {
-	rcc, err := la.GetMultiClaims(token)
+// sessionId returns the id of the session of the token, see WatchRevoked.
+func (la *LocalAuthOf[T]) sessionId(token string) string {
+	return hashToken(la.Hasher, token)
+}
+
+// clock
+func (la *LocalAuthOf[T]) clock() Clock {
+	return clockOf(la.Clock)
+}
+
+// setDeadline keeps the time when the session of the token expires by the Clock.
+func (la *LocalAuthOf[T]) setDeadline(token string, expire time.Duration) {
+	la.Cache.Set(GtSessionDeadlinePrefix+token, clockOf(la.Clock).Now().Add(expire), expire)
+}
+
+// getDeadline returns the time when the session of the token expires by the Clock.
+func (la *LocalAuthOf[T]) getDeadline(token string) (time.Time, bool) {
+	if deadline, found := la.Cache.Get(GtSessionDeadlinePrefix + token); found {
+		return deadline.(time.Time), true
+	}
+	return time.Time{}, false
+}
+
+// isAlive reports whether the session of the token exists and is not expired by the Clock,
+// the expired session is removed.
+func (la *LocalAuthOf[T]) isAlive(token string) bool {
+	if _, found := la.Cache.Get(GtSessionTokenPrefix + token); !found {
+		return false
+	}
+	if deadline, found := la.getDeadline(token); found && !clockOf(la.Clock).Now().Before(deadline) {
+		if la.Events != nil {
+			var rcc *MultiClaims
+			if claims, ok := la.Cache.Get(GtSessionTokenPrefix + token); ok {
+				if cla, ok := claims.(T); ok {
+					rcc = cla.Standard()
+				}
+			}
+			defer dispatch(la.Events, la.Clock, Event{Type: EventRevoke, Token: token, Claims: rcc, Reason: RevokeReasonExpired})
+		}
+		la.Cache.Delete(GtSessionBindUserPrefix + token)
+		la.Cache.Delete(GtSessionTokenPrefix + token)
+		la.Cache.Delete(GtSessionCsrfPrefix + token)
+		la.Cache.Delete(GtSessionDeadlinePrefix + token)
+		return false
+	}
+	return true
+}
+
+func (la *LocalAuthOf[T]) syncUserTokenCache(token string) error {
+	claims, err := la.getMultiClaims(token)
 	if err != nil {
 		return err
 	}
+	rcc := claims.Standard()
 
 	userPrefixKey := getUserPrefixKey(rcc.AuthorityType, rcc.Id)
-	ts := tokens{}
-	if uTokens, ok := la.Cache.Get(userPrefixKey); ok && uTokens != nil {
-		ts = uTokens.(tokens)
+	la.addIndexToken(userPrefixKey, token)
+	la.addIndexToken(getUserIdPrefixKey(rcc.Id), token)
+	if rcc.TenancyId > 0 {
+		la.addIndexToken(getTenancyPrefixKey(rcc.TenancyId), token)
+	}
+	for _, authorityId := range getAuthorityIds(rcc.AuthorityId) {
+		la.addIndexToken(getAuthorityPrefixKey(authorityId), token)
 	}
-	ts = append(ts, token)
-	la.Cache.Set(userPrefixKey, ts, cache.NoExpiration)
 
-	la.Cache.Set(GtSessionBindUserPrefix+token, userPrefixKey, getTokenExpire(rcc.LoginType))
+	la.Cache.Set(GtSessionBindUserPrefix+token, userPrefixKey, GetTokenExpire(rcc.LoginType))
 	return nil
 }
 
-func (la *LocalAuth) DelUserTokenCache(token string) error {
-	rcc, err := la.GetMultiClaims(token)
+func (la *LocalAuthOf[T]) DelUserTokenCache(token string) error {
+	if err := checkToken(la.Generator, token); err != nil {
+		return err
+	}
+	id := hashToken(la.Hasher, token)
+	rcc, err := la.delSession(id)
 	if err != nil {
 		return err
 	}
+	dispatch(la.Events, la.Clock, Event{Type: EventLogout, Token: id, Claims: rcc})
+	return nil
+}
+
+// delSession removes the session of the id and its indexes, the claims of the session are returned.
+func (la *LocalAuthOf[T]) delSession(token string) (*MultiClaims, error) {
+	claims, err := la.getMultiClaims(token)
+	if err != nil {
+		return nil, err
+	}
+	rcc := claims.Standard()
 	if rcc == nil {
-		return errors.New("token cache is nil")
+		return nil, errors.New("token cache is nil")
 	}
 
-	userPrefixKey := getUserPrefixKey(rcc.AuthorityType, rcc.Id)
-	if utokens, ok := la.Cache.Get(userPrefixKey); ok && utokens != nil {
-		t := utokens.(tokens)
-		for index, u := range t {
-			if u == token {
-				if len(t) == 1 {
-					utokens = nil
-				} else {
-					utokens = append(t[0:index], t[index:]...)
-				}
-			}
-		}
-		la.Cache.Set(userPrefixKey, utokens, cache.NoExpiration)
-
-	}
+	la.delSessionIndex(rcc, token)
 	err = la.delTokenCache(token)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return rcc, nil
+}
+
+// addIndexToken
+func (la *LocalAuthOf[T]) addIndexToken(indexKey, token string) {
+	ts := tokens{}
+	if iTokens, ok := la.Cache.Get(indexKey); ok && iTokens != nil {
+		ts = iTokens.(tokens)
+	}
+	ts = append(ts, token)
+	la.Cache.Set(indexKey, ts, cache.NoExpiration)
+}
+
+// delIndexToken
+func (la *LocalAuthOf[T]) delIndexToken(indexKey, token string) {
+	if iTokens, ok := la.Cache.Get(indexKey); ok && iTokens != nil {
+		la.Cache.Set(indexKey, iTokens.(tokens).remove(token), cache.NoExpiration)
+	}
+}
+
+// delSessionIndex removes the token from the user, tenancy and authority indexes.
+func (la *LocalAuthOf[T]) delSessionIndex(rcc *MultiClaims, token string) {
+	la.delIndexToken(getUserPrefixKey(rcc.AuthorityType, rcc.Id), token)
+	la.delIndexToken(getUserIdPrefixKey(rcc.Id), token)
+	if rcc.TenancyId > 0 {
+		la.delIndexToken(getTenancyPrefixKey(rcc.TenancyId), token)
+	}
+	for _, authorityId := range getAuthorityIds(rcc.AuthorityId) {
+		la.delIndexToken(getAuthorityPrefixKey(authorityId), token)
+	}
 }
 
 // delTokenCache
-func (la *LocalAuth) delTokenCache(token string) error {
+func (la *LocalAuthOf[T]) delTokenCache(token string) error {
 	la.Cache.Delete(GtSessionBindUserPrefix + token)
 	la.Cache.Delete(GtSessionTokenPrefix + token)
+	la.Cache.Delete(GtSessionCsrfPrefix + token)
+	la.Cache.Delete(GtSessionDeadlinePrefix + token)
+	DefaultRevokeWatcher.Notify(token)
 	return nil
 }
 
-func (la *LocalAuth) UpdateUserTokenCacheExpire(token string) error {
+func (la *LocalAuthOf[T]) UpdateUserTokenCacheExpire(token string) error {
 	rsv2, err := la.GetMultiClaims(token)
 	if err != nil {
 		return err
 	}
-	if rsv2 == nil {
+	token = hashToken(la.Hasher, token)
+	if rsv2.Standard() == nil {
 		return errors.New("token cache is nil")
 	}
-	la.Cache.Set(GtSessionBindUserPrefix+token, rsv2, getTokenExpire(rsv2.LoginType))
-	la.Cache.Set(GtSessionTokenPrefix+token, rsv2, getTokenExpire(rsv2.LoginType))
+	loginType := rsv2.Standard().LoginType
+	la.Cache.Set(GtSessionBindUserPrefix+token, rsv2, GetTokenExpire(loginType))
+	la.Cache.Set(GtSessionTokenPrefix+token, rsv2, GetTokenExpire(loginType))
+	la.setDeadline(token, GetTokenExpire(loginType))
+	if secret, found := la.Cache.Get(GtSessionCsrfPrefix + token); found {
+		la.Cache.Set(GtSessionCsrfPrefix+token, secret, GetTokenExpire(loginType))
+	}
 
+	dispatch(la.Events, la.Clock, Event{Type: EventRenew, Token: token, Claims: rsv2.Standard()})
 	return nil
 }
 
-func (la *LocalAuth) GetMultiClaims(token string) (*MultiClaims, error) {
+func (la *LocalAuthOf[T]) GetMultiClaims(token string) (T, error) {
+	claims, err := la.findMultiClaims(token)
+	if err != nil {
+		la.verifyFailed(token, RequestInfo{}, err)
+	}
+	return claims, err
+}
+
+// findMultiClaims returns the claims of the token.
+func (la *LocalAuthOf[T]) findMultiClaims(token string) (T, error) {
+	var empty T
+	if err := checkToken(la.Generator, token); err != nil {
+		return empty, err
+	}
+	return la.getMultiClaims(hashToken(la.Hasher, token))
+}
+
+// verifyFailed dispatches the EventVerifyFailed event of the token.
+func (la *LocalAuthOf[T]) verifyFailed(token string, req RequestInfo, err error) {
+	dispatch(la.Events, la.Clock, Event{Type: EventVerifyFailed, Token: hashToken(la.Hasher, token), Request: req, Err: err})
+}
+
+// getMultiClaims returns the claims of the session id.
+func (la *LocalAuthOf[T]) getMultiClaims(token string) (T, error) {
+	var empty T
+	if !la.isAlive(token) {
+		return empty, ErrTokenInvalid
+	}
 	sKey := GtSessionTokenPrefix + token
 	if food, found := la.Cache.Get(sKey); !found || food == nil {
-		return nil, ErrTokenInvalid
-	} else {
-		return food.(*MultiClaims), nil
+		return empty, ErrTokenInvalid
+	} else if cla, ok := food.(T); ok {
+		return cla, nil
 	}
+	return empty, ErrTokenInvalid
 }
 
-// GetTokenByClaims
-func (la *LocalAuth) GetTokenByClaims(cla *MultiClaims) (string, error) {
+// GetTokenByClaims returns the token of the session of the claims, or its hash with a Hasher.
+func (la *LocalAuthOf[T]) GetTokenByClaims(claims T) (string, error) {
+	cla := claims.Standard()
 	userTokens, err := la.getUserTokens(cla.AuthorityType, cla.Id)
 	if err != nil {
 		return "", err
@@ -141,7 +294,8 @@ func (la *LocalAuth) GetTokenByClaims(cla *MultiClaims) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	for token, existCla := range clas {
+	for token, exist := range clas {
+		existCla := exist.Standard()
 		if cla.AuthType == existCla.AuthType &&
 			cla.Id == existCla.Id &&
 			cla.AuthorityType == existCla.AuthorityType &&
@@ -155,7 +309,7 @@ func (la *LocalAuth) GetTokenByClaims(cla *MultiClaims) (string, error) {
 }
 
 // getUserTokens
-func (la *LocalAuth) getUserTokens(authorityType int, userId string) (tokens, error) {
+func (la *LocalAuthOf[T]) getUserTokens(authorityType int, userId string) (tokens, error) {
 	if utokens, ok := la.Cache.Get(getUserPrefixKey(authorityType, userId)); ok && utokens != nil {
 		return utokens.(tokens), nil
 	}
@@ -163,10 +317,10 @@ func (la *LocalAuth) getUserTokens(authorityType int, userId string) (tokens, er
 }
 
 // getMultiClaimses
-func (la *LocalAuth) getMultiClaimses(tokens tokens) (map[string]*MultiClaims, error) {
-	clas := make(map[string]*MultiClaims, la.getUserTokenMaxCount())
+func (la *LocalAuthOf[T]) getMultiClaimses(tokens tokens) (map[string]T, error) {
+	clas := make(map[string]T, la.getUserTokenMaxCount())
 	for _, token := range tokens {
-		cla, err := la.GetMultiClaims(token)
+		cla, err := la.getMultiClaims(token)
 		if err != nil {
 			continue
 		}
@@ -176,27 +330,23 @@ func (la *LocalAuth) getMultiClaimses(tokens tokens) (map[string]*MultiClaims, e
 	return clas, nil
 }
 
-func (la *LocalAuth) isUserTokenOver(authorityType int, userId string) bool {
+func (la *LocalAuthOf[T]) isUserTokenOver(authorityType int, userId string) bool {
 	return la.getUserTokenCount(authorityType, userId) >= la.getUserTokenMaxCount()
 }
 
 // getUserTokenCount
-func (la *LocalAuth) getUserTokenCount(authorityType int, userId string) int64 {
+func (la *LocalAuthOf[T]) getUserTokenCount(authorityType int, userId string) int64 {
 	return la.checkMaxCount(authorityType, userId)
 }
 
-func (la *LocalAuth) checkMaxCount(authorityType int, userId string) int64 {
+func (la *LocalAuthOf[T]) checkMaxCount(authorityType int, userId string) int64 {
 	utokens, _ := la.getUserTokens(authorityType, userId)
 	if utokens == nil {
 		return 0
 	}
-	for index, u := range utokens {
-		if _, found := la.Cache.Get(GtSessionTokenPrefix + u); !found {
-			if len(utokens) == 1 {
-				utokens = nil
-			} else {
-				utokens = append(utokens[0:index], utokens[index:]...)
-			}
+	for _, u := range utokens {
+		if !la.isAlive(u) {
+			utokens = utokens.remove(u)
 		}
 	}
 	la.Cache.Set(getUserPrefixKey(authorityType, userId), utokens, cache.NoExpiration)
@@ -205,7 +355,7 @@ func (la *LocalAuth) checkMaxCount(authorityType int, userId string) int64 {
 }
 
 // getUserTokenMaxCount
-func (la *LocalAuth) getUserTokenMaxCount() int64 {
+func (la *LocalAuthOf[T]) getUserTokenMaxCount() int64 {
 	if count, found := la.Cache.Get(GtSessionUserMaxTokenPrefix); !found {
 		return GtSessionUserMaxTokenDefault
 	} else {
@@ -214,36 +364,136 @@ func (la *LocalAuth) getUserTokenMaxCount() int64 {
 }
 
 // SetUserTokenMaxCount
-func (la *LocalAuth) SetUserTokenMaxCount(tokenMaxCount int64) error {
+func (la *LocalAuthOf[T]) SetUserTokenMaxCount(tokenMaxCount int64) error {
 	la.Cache.Set(GtSessionUserMaxTokenPrefix, tokenMaxCount, cache.NoExpiration)
 	return nil
 }
 
 // CleanUserTokenCache
-func (la *LocalAuth) CleanUserTokenCache(authorityType int, userId string) error {
+func (la *LocalAuthOf[T]) CleanUserTokenCache(authorityType int, userId string) error {
 	utokens, _ := la.getUserTokens(authorityType, userId)
 	if utokens == nil {
 		return nil
 	}
 
 	for _, token := range utokens {
+		var rcc *MultiClaims
+		if la.Events != nil {
+			rcc = standardOf(la.getMultiClaims(token))
+		}
 		err := la.delTokenCache(token)
 		if err != nil {
 			continue
 		}
+		dispatch(la.Events, la.Clock, Event{Type: EventRevoke, Token: token, Claims: rcc, Reason: RevokeReasonCleaned})
 	}
 	la.Cache.Delete(getUserPrefixKey(authorityType, userId))
 
 	return nil
 }
 
+// getIndexTokens returns the tokens of the index, expired ones are removed from the index.
+func (la *LocalAuthOf[T]) getIndexTokens(indexKey string) tokens {
+	iTokens, ok := la.Cache.Get(indexKey)
+	if !ok || iTokens == nil {
+		return nil
+	}
+	t := iTokens.(tokens)
+	ts := make(tokens, 0, len(t))
+	for _, u := range t {
+		if la.isAlive(u) {
+			ts = append(ts, u)
+		}
+	}
+	la.Cache.Set(indexKey, ts, cache.NoExpiration)
+	return ts
+}
+
+// cleanIndexTokenCache removes every session of the index.
+func (la *LocalAuthOf[T]) cleanIndexTokenCache(indexKey string) {
+	for _, token := range la.getIndexTokens(indexKey) {
+		rcc, err := la.delSession(token)
+		if err != nil {
+			continue
+		}
+		dispatch(la.Events, la.Clock, Event{Type: EventRevoke, Token: token, Claims: rcc, Reason: RevokeReasonCleaned})
+	}
+	la.Cache.Delete(indexKey)
+}
+
+// CleanAllUserTokenCache removes every session of the user whatever the authority type is.
+func (la *LocalAuthOf[T]) CleanAllUserTokenCache(userId string) error {
+	la.cleanIndexTokenCache(getUserIdPrefixKey(userId))
+	return nil
+}
+
+// GetTenancyTokens returns the sessions of the tenancy by token, or by its hash with a Hasher.
+func (la *LocalAuthOf[T]) GetTenancyTokens(tenancyId uint) (map[string]T, error) {
+	return la.getMultiClaimses(la.getIndexTokens(getTenancyPrefixKey(tenancyId)))
+}
+
+// GetTenancyTokenCount
+func (la *LocalAuthOf[T]) GetTenancyTokenCount(tenancyId uint) (int64, error) {
+	return int64(len(la.getIndexTokens(getTenancyPrefixKey(tenancyId)))), nil
+}
+
+// CleanTenancyTokenCache removes every session of the tenancy.
+func (la *LocalAuthOf[T]) CleanTenancyTokenCache(tenancyId uint) error {
+	la.cleanIndexTokenCache(getTenancyPrefixKey(tenancyId))
+	return nil
+}
+
+// GetAuthorityTokens returns the sessions which hold the authority id by token, or by its hash with a Hasher.
+func (la *LocalAuthOf[T]) GetAuthorityTokens(authorityId string) (map[string]T, error) {
+	return la.getMultiClaimses(la.getIndexTokens(getAuthorityPrefixKey(authorityId)))
+}
+
+// CleanAuthorityTokenCache removes every session which holds the authority id.
+func (la *LocalAuthOf[T]) CleanAuthorityTokenCache(authorityId string) error {
+	la.cleanIndexTokenCache(getAuthorityPrefixKey(authorityId))
+	return nil
+}
+
+// SetCsrfSecret generates a new csrf secret and binds it to the session of the token,
+// the secret expires with the session.
+func (la *LocalAuthOf[T]) SetCsrfSecret(token string) (string, error) {
+	token = hashToken(la.Hasher, token)
+	if !la.isAlive(token) {
+		return "", ErrTokenInvalid
+	}
+	secret, err := GetToken()
+	if err != nil {
+		return "", err
+	}
+	expire := cache.DefaultExpiration
+	if deadline, found := la.getDeadline(token); found {
+		expire = deadline.Sub(clockOf(la.Clock).Now())
+	}
+	la.Cache.Set(GtSessionCsrfPrefix+token, secret, expire)
+	return secret, nil
+}
+
+// GetCsrfSecret returns the csrf secret bound to the session of the token.
+func (la *LocalAuthOf[T]) GetCsrfSecret(token string) (string, error) {
+	token = hashToken(la.Hasher, token)
+	if !la.isAlive(token) {
+		return "", ErrCsrfInvalid
+	}
+	if secret, found := la.Cache.Get(GtSessionCsrfPrefix + token); found {
+		if s, ok := secret.(string); ok {
+			return s, nil
+		}
+	}
+	return "", ErrCsrfInvalid
+}
+
 // IsRole
-func (la *LocalAuth) IsRole(token string, authorityType int) (bool, error) {
+func (la *LocalAuthOf[T]) IsRole(token string, authorityType int) (bool, error) {
 	rcc, err := la.GetMultiClaims(token)
 	if err != nil {
 		return false, fmt.Errorf("get User's infomation return error: %w", err)
 	}
-	return rcc.AuthorityType == authorityType, nil
+	return rcc.Standard().AuthorityType == authorityType, nil
 }
 
-func (la *LocalAuth) Close() {}
+func (la *LocalAuthOf[T]) Close() {}
@@ -10,20 +10,8 @@ import (
 var (
 	localAuth    = NewLocalAuth()
 	tToken       = "TVRReU1EVTFOek13TmpFd09UWXlPRFF4TmcuTWpBeU1TMHdOeTB5T1ZRd09Ub3pNRG95T1Nzd09Eb3dNQQ.MTQyMDU1NzMwNjEwOTYyODQxNg"
-	customClaims = New(
-		&Multi{
-			Id:            uint(1),
-			Username:      "username",
-			TenancyId:     1,
-			TenancyName:   "username",
-			AuthorityIds:  []string{"999"},
-			AuthorityType: AdminAuthority,
-			LoginType:     LoginTypeWeb,
-			AuthType:      LoginTypeWeb,
-			ExpiresAt:     time.Now().Local().Add(RedisSessionTimeoutWeb).Unix(),
-		},
-	)
-	userKey = getUserPrefixKey(customClaims.AuthorityType, customClaims.Id)
+	customClaims = newTestClaims(1, withTenancy(1))
+	userKey      = getUserPrefixKey(customClaims.AuthorityType, customClaims.Id)
 )
 
 func TestNewLocalAuth(t *testing.T) {
@@ -102,6 +90,26 @@ func TestGenerateToken(t *testing.T) {
 	})
 }
 
+func TestLocalTokenGenerator(t *testing.T) {
+	t.Run("test generate token by the generator", func(t *testing.T) {
+		auth := NewLocalAuth()
+		auth.Generator = TokenGeneratorFunc(func() (string, error) {
+			return "generated-token", nil
+		})
+		token, _, err := auth.GenerateToken(customClaims)
+		if err != nil {
+			t.Fatalf("generate token %v", err)
+		}
+		defer auth.DelUserTokenCache(token)
+		if token != "generated-token" {
+			t.Errorf("generate token want generated-token but get %s", token)
+		}
+		if _, err := auth.GetMultiClaims(token); err != nil {
+			t.Errorf("get custom claims %v", err)
+		}
+	})
+}
+
 func TestToCache(t *testing.T) {
 	t.Run("test to cache", func(t *testing.T) {
 		err := localAuth.toCache(tToken, customClaims)
@@ -400,3 +408,155 @@ func TestLocalGetMultiClaimses(t *testing.T) {
 	})
 
 }
+
+func TestLocalAuthOfCustomClaims(t *testing.T) {
+	auth := NewLocalAuthOf[*avatarClaims]()
+	claims := newAvatarTestClaims(7)
+	defer auth.CleanUserTokenCache(claims.AuthorityType, claims.Id)
+	t.Run("test custom claims", func(t *testing.T) {
+		token, _, err := auth.GenerateToken(claims)
+		if err != nil {
+			t.Fatalf("generate token %v", err)
+		}
+		cc, err := auth.GetMultiClaims(token)
+		if err != nil {
+			t.Fatalf("get custom claims %v", err)
+		}
+		if cc.Avatar != claims.Avatar {
+			t.Errorf("get custom avatar want %v but get %v", claims.Avatar, cc.Avatar)
+		}
+		if cc.Id != claims.Id {
+			t.Errorf("get custom id want %v but get %v", claims.Id, cc.Id)
+		}
+	})
+}
+
+func TestLocalCleanTenancyTokenCache(t *testing.T) {
+	var tenancyId uint = 99
+	for _, cla := range []*MultiClaims{
+		newTestClaims(991, withTenancy(tenancyId), withAuthorityType(TenancyAuthority), withLoginType(LoginTypeWeb)),
+		newTestClaims(991, withTenancy(tenancyId), withAuthorityType(TenancyAuthority), withLoginType(LoginTypeApp)),
+		newTestClaims(992, withTenancy(tenancyId), withAuthorityType(TenancyAuthority), withLoginType(LoginTypeWeb)),
+	} {
+		if _, _, err := localAuth.GenerateToken(cla); err != nil {
+			t.Fatalf("generate token %v", err)
+		}
+	}
+	t.Run("test tenancy tokens", func(t *testing.T) {
+		count, err := localAuth.GetTenancyTokenCount(tenancyId)
+		if err != nil {
+			t.Fatalf("get tenancy token count %v", err)
+		}
+		if count != 3 {
+			t.Errorf("tenancy token count want 3 but get %d", count)
+		}
+		clas, err := localAuth.GetTenancyTokens(tenancyId)
+		if err != nil {
+			t.Fatalf("get tenancy tokens %v", err)
+		}
+		if len(clas) != 3 {
+			t.Errorf("tenancy tokens want 3 but get %d", len(clas))
+		}
+	})
+	t.Run("test clean tenancy token cache", func(t *testing.T) {
+		if err := localAuth.CleanTenancyTokenCache(tenancyId); err != nil {
+			t.Fatalf("clean tenancy token cache %v", err)
+		}
+		count, _ := localAuth.GetTenancyTokenCount(tenancyId)
+		if count != 0 {
+			t.Errorf("tenancy token count want 0 but get %d", count)
+		}
+		if userCount := localAuth.getUserTokenCount(TenancyAuthority, "991"); userCount != 0 {
+			t.Errorf("user token count want 0 but get %d", userCount)
+		}
+	})
+}
+
+func TestLocalCleanAuthorityTokenCache(t *testing.T) {
+	for _, cla := range []*MultiClaims{
+		newTestClaims(993, withAuthorityIds("301", "302")),
+		newTestClaims(994, withAuthorityIds("302")),
+	} {
+		if _, _, err := localAuth.GenerateToken(cla); err != nil {
+			t.Fatalf("generate token %v", err)
+		}
+	}
+	t.Run("test authority tokens", func(t *testing.T) {
+		clas, err := localAuth.GetAuthorityTokens("302")
+		if err != nil {
+			t.Fatalf("get authority tokens %v", err)
+		}
+		if len(clas) != 2 {
+			t.Errorf("authority tokens want 2 but get %d", len(clas))
+		}
+	})
+	t.Run("test clean authority token cache", func(t *testing.T) {
+		if err := localAuth.CleanAuthorityTokenCache("301"); err != nil {
+			t.Fatalf("clean authority token cache %v", err)
+		}
+		clas, err := localAuth.GetAuthorityTokens("302")
+		if err != nil {
+			t.Fatalf("get authority tokens %v", err)
+		}
+		if len(clas) != 1 {
+			t.Errorf("authority tokens want 1 but get %d", len(clas))
+		}
+		for _, cla := range clas {
+			if cla.Id != "994" {
+				t.Errorf("authority token user want 994 but get %s", cla.Id)
+			}
+		}
+	})
+}
+
+func TestLocalCleanAllUserTokenCache(t *testing.T) {
+	for _, authorityType := range []int{AdminAuthority, GeneralAuthority} {
+		if _, _, err := localAuth.GenerateToken(newTestClaims(995, withAuthorityType(authorityType))); err != nil {
+			t.Fatalf("generate token %v", err)
+		}
+	}
+	t.Run("test clean all user token cache", func(t *testing.T) {
+		if err := localAuth.CleanAllUserTokenCache("995"); err != nil {
+			t.Fatalf("clean all user token cache %v", err)
+		}
+		for _, authorityType := range []int{AdminAuthority, GeneralAuthority} {
+			if count := localAuth.getUserTokenCount(authorityType, "995"); count != 0 {
+				t.Errorf("user token count of authority type %d want 0 but get %d", authorityType, count)
+			}
+		}
+	})
+}
+
+func TestLocalCsrfSecret(t *testing.T) {
+	token, _, err := localAuth.GenerateToken(newTestClaims(996))
+	if err != nil {
+		t.Fatalf("generate token %v", err)
+	}
+	t.Run("test csrf secret", func(t *testing.T) {
+		if _, err := localAuth.GetCsrfSecret(token); !errors.Is(err, ErrCsrfInvalid) {
+			t.Errorf("get csrf secret want %v but get %v", ErrCsrfInvalid, err)
+		}
+		secret, err := localAuth.SetCsrfSecret(token)
+		if err != nil {
+			t.Fatalf("set csrf secret %v", err)
+		}
+		got, err := localAuth.GetCsrfSecret(token)
+		if err != nil {
+			t.Fatalf("get csrf secret %v", err)
+		}
+		if got != secret {
+			t.Errorf("get csrf secret want %s but get %s", secret, got)
+		}
+	})
+	t.Run("test csrf secret deleted with session", func(t *testing.T) {
+		if err := localAuth.DelUserTokenCache(token); err != nil {
+			t.Fatalf("del user token cache %v", err)
+		}
+		if _, err := localAuth.GetCsrfSecret(token); !errors.Is(err, ErrCsrfInvalid) {
+			t.Errorf("get csrf secret want %v but get %v", ErrCsrfInvalid, err)
+		}
+		if _, err := localAuth.SetCsrfSecret(token); !errors.Is(err, ErrTokenInvalid) {
+			t.Errorf("set csrf secret want %v but get %v", ErrTokenInvalid, err)
+		}
+	})
+}
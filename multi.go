@@ -3,16 +3,25 @@ package multi
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"github.com/golang-jwt/jwt"
 )
 
 const (
 	GtSessionTokenPrefix        = "GST:"           // token perfix
 	GtSessionBindUserPrefix     = "GSBU:"          // token perfix for bind user
 	GtSessionUserPrefix         = "GSU:"           // user perfix
+	GtSessionUserIdPrefix       = "GSUI:"          // user perfix for all authority types
+	GtSessionTenancyPrefix      = "GSTE:"          // tenancy perfix
+	GtSessionAuthorityPrefix    = "GSA:"           // authority perfix
+	GtSessionCsrfPrefix         = "GSC:"           // csrf secret perfix
+	GtSessionBlockPrefix        = "GSB:"           // blocked token perfix
+	GtSessionDeadlinePrefix     = "GSE:"           // session deadline perfix of the local driver
 	GtSessionUserMaxTokenPrefix = "GTUserMaxToken" // user max token prefix
+	GtSessionRevokeChannel      = "GTRevoke"       // channel of revoked tokens
 )
 
 var (
@@ -25,6 +34,8 @@ var (
 	ErrEmptyToken        = errors.New("TOKEN IS EMPTY")
 	ErrOverMaxTokenCount = errors.New("OVER LOGIN DEVICE LIMIT")
 	ErrForJwt            = errors.New("JWT NOT SUPPORT THIS FEATURE")
+	ErrDriverNil         = internal(errors.New("AUTH DRIVER IS NIL"))
+	ErrCsrfInvalid       = errors.New("CSRF TOKEN IS INVALID")
 )
 
 // role's type
@@ -61,34 +72,54 @@ var (
 
 // InitDriver
 func InitDriver(c *Config) error {
+	driver, err := NewDriver[*MultiClaims](c)
+	if err != nil {
+		return err
+	}
+	AuthDriver = driver
+	return nil
+}
+
+// NewDriver creates a driver which stores and returns the claims type T.
+// T is usually a pointer to a struct embedding MultiClaims.
+func NewDriver[T Claims](c *Config) (AuthenticationOf[T], error) {
 	if c.TokenMaxCount == 0 {
 		c.TokenMaxCount = 10
 	}
 	switch c.DriverType {
 	case "redis":
-		driver, err := NewRedisAuth(c.UniversalClient)
+		driver, err := NewRedisAuthOf[T](c.UniversalClient)
 		if err != nil {
-			return err
+			return nil, err
 		}
-
-		AuthDriver = driver
-		err = AuthDriver.SetUserTokenMaxCount(c.TokenMaxCount)
+		driver.Clock = c.Clock
+		driver.Generator = c.TokenGenerator
+		driver.Hasher = c.TokenHasher
+		driver.KeyRing = c.ClaimsKeyRing
+		driver.Events = c.Events
+		err = driver.SetUserTokenMaxCount(c.TokenMaxCount)
 		if err != nil {
-			return err
+			return nil, err
 		}
+		return driver, nil
 	case "local":
-		AuthDriver = NewLocalAuth()
-		err := AuthDriver.SetUserTokenMaxCount(c.TokenMaxCount)
+		driver := NewLocalAuthOf[T]()
+		driver.Clock = c.Clock
+		driver.Generator = c.TokenGenerator
+		driver.Hasher = c.TokenHasher
+		driver.Events = c.Events
+		err := driver.SetUserTokenMaxCount(c.TokenMaxCount)
 		if err != nil {
-			return err
+			return nil, err
 		}
-	case "jwt":
-		AuthDriver = NewJwtAuth(c.HmacSecret)
+		return driver, nil
 	default:
-		AuthDriver = NewJwtAuth(c.HmacSecret)
+		driver := NewJwtAuthOf[T](c.HmacSecret)
+		driver.Clock = c.Clock
+		driver.Leeway = c.JwtLeeway
+		driver.Events = c.Events
+		return driver, nil
 	}
-
-	return nil
 }
 
 // Multi
@@ -101,6 +132,7 @@ type Multi struct {
 	AuthorityType int      `json:"authorityType,omitempty"`
 	LoginType     int      `json:"loginType,omitempty"`
 	AuthType      int      `json:"authType,omitempty"`
+	Issuer        string   `json:"iss,omitempty"`
 	CreationDate  int64    `json:"creationData,omitempty"`
 	ExpiresAt     int64    `json:"expiresAt,omitempty"`
 }
@@ -110,52 +142,162 @@ type Config struct {
 	TokenMaxCount   int64
 	UniversalClient redis.UniversalClient
 	HmacSecret      []byte
+	JwtLeeway       time.Duration   // leeway of the expiry checked by the jwt driver, see JwtAuthOf
+	Clock           Clock           // clock of the drivers, the redis server keeps the session expiry by its own time
+	TokenGenerator  TokenGenerator  // token generator of the redis and local drivers
+	TokenHasher     *TokenHasher    // hasher of the tokens at rest in the redis and local drivers
+	ClaimsKeyRing   *KeyRing        // key ring which encrypts the claims at rest in the redis driver
+	Events          EventDispatcher // receiver of the session lifecycle events of the drivers
 }
 
 type (
-	// TokenValidator provides further token and claims validation.
+	// TokenValidator provides further token validation, it runs before the claims are decoded.
 	TokenValidator interface {
-		// ValidateToken accepts the token, the claims extracted from that
-		// and any error that may caused by claims validation (e.g. ErrExpired)
-		// or the previous validator.
+		// ValidateToken accepts the token and any error that may caused
+		// by the previous validator.
 		// A token validator can skip the builtin validation and return a nil error.
 		// Usage:
-		//  func(v *myValidator) ValidateToken(token []byte, standardClaims Claims, err error) error {
+		//  func(v *myValidator) ValidateToken(token []byte, err error) error {
 		//    if err!=nil { return err } <- to respect the previous error
 		//    // otherwise return nil or any custom error.
 		//  }
 		//
+		// The validators which need the decoded claims or the request
+		// implement ClaimsValidator or RequestValidator.
 		// Look `Blocklist`, `Expected` and `Leeway` for builtin implementations.
 		ValidateToken(token []byte, err error) error
 	}
 
 	// TokenValidatorFunc is the interface-as-function shortcut for a TokenValidator.
 	TokenValidatorFunc func(token []byte, err error) error
+
+	// ClaimsValidator is a TokenValidator which validates the decoded claims too.
+	// ValidateClaims accepts the error of claims.Valid() or the previous claims validator,
+	// like ValidateToken it can skip the builtin validation and return a nil error.
+	ClaimsValidator interface {
+		TokenValidator
+		ValidateClaims(token []byte, claims *MultiClaims, err error) error
+	}
+
+	// RequestValidator is a TokenValidator which validates the decoded claims against the request,
+	// it runs after the ClaimsValidators and accepts their error like ValidateClaims.
+	RequestValidator interface {
+		TokenValidator
+		ValidateRequest(token []byte, claims *MultiClaims, req RequestInfo, err error) error
+	}
+
+	// RequestValidatorFunc is the interface-as-function shortcut for a RequestValidator.
+	RequestValidatorFunc func(token []byte, claims *MultiClaims, req RequestInfo, err error) error
 )
 
+// RequestInfo is the request metadata passed to the RequestValidators.
+type RequestInfo struct {
+	Method string
+	Path   string
+	IP     string
+}
+
 // ValidateToken completes the ValidateToken interface.
 // It calls itself.
 func (fn TokenValidatorFunc) ValidateToken(token []byte, err error) error {
 	return fn(token, err)
 }
 
+// ValidateToken completes the TokenValidator interface, the token is checked by ValidateRequest.
+func (fn RequestValidatorFunc) ValidateToken(token []byte, err error) error {
+	return err
+}
+
+// ValidateRequest completes the RequestValidator interface.
+// It calls itself.
+func (fn RequestValidatorFunc) ValidateRequest(token []byte, claims *MultiClaims, req RequestInfo, err error) error {
+	return fn(token, claims, req, err)
+}
+
+// ValidateClaims validates the decoded claims by claims.Valid(),
+// the error is passed through the ClaimsValidator and then the RequestValidator of the validators.
+// The RequestValidators get an empty RequestInfo, see ValidateRequest.
+func ValidateClaims[T Claims](token []byte, claims T, validators ...TokenValidator) error {
+	return ValidateRequest(token, claims, RequestInfo{}, validators...)
+}
+
+// ValidateRequest is ValidateClaims with the request metadata for the RequestValidators.
+func ValidateRequest[T Claims](token []byte, claims T, req RequestInfo, validators ...TokenValidator) error {
+	return ValidateRequestAt(DefaultClock.Now(), token, claims, req, validators...)
+}
+
+// ValidateRequestAt is ValidateRequest with the claims validated at the time t,
+// the adapters pass the time of the driver clock, see DriverClock.
+func ValidateRequestAt[T Claims](t time.Time, token []byte, claims T, req RequestInfo, validators ...TokenValidator) error {
+	err := validAt(claims, t)
+	for _, validator := range validators {
+		if cv, ok := validator.(ClaimsValidator); ok {
+			err = cv.ValidateClaims(token, claims.Standard(), err)
+		}
+	}
+	for _, validator := range validators {
+		if rv, ok := validator.(RequestValidator); ok {
+			err = rv.ValidateRequest(token, claims.Standard(), req, err)
+		}
+	}
+	return err
+}
+
+// DriverClock returns the Clock of the drivers of this package, or DefaultClock for the other drivers.
+func DriverClock(driver interface{}) Clock {
+	if d, ok := driver.(interface{ clock() Clock }); ok {
+		return d.clock()
+	}
+	return DefaultClock
+}
+
 var AuthDriver Authentication
 
-// Authentication
-type Authentication interface {
-	GenerateToken(claims *MultiClaims) (string, int64, error)
+// Claims is the constraint for the claims type stored by the drivers.
+// Any pointer to a struct embedding MultiClaims satisfies it.
+type Claims interface {
+	jwt.Claims
+	Standard() *MultiClaims
+}
+
+// Authentication is the driver for the default MultiClaims.
+type Authentication = AuthenticationOf[*MultiClaims]
+
+// AuthenticationOf
+type AuthenticationOf[T Claims] interface {
+	GenerateToken(claims T) (string, int64, error)
 	DelUserTokenCache(token string) error
 	UpdateUserTokenCacheExpire(token string) error
-	GetMultiClaims(token string) (*MultiClaims, error)
-	GetTokenByClaims(claims *MultiClaims) (string, error)
+	GetMultiClaims(token string) (T, error)
+	GetTokenByClaims(claims T) (string, error)
 	CleanUserTokenCache(authorityType int, userId string) error
+	CleanAllUserTokenCache(userId string) error
 	SetUserTokenMaxCount(tokenMaxCount int64) error
+	CleanTenancyTokenCache(tenancyId uint) error
+	GetTenancyTokens(tenancyId uint) (map[string]T, error)
+	GetTenancyTokenCount(tenancyId uint) (int64, error)
+	CleanAuthorityTokenCache(authorityId string) error
+	GetAuthorityTokens(authorityId string) (map[string]T, error)
+	SetCsrfSecret(token string) (string, error)
+	GetCsrfSecret(token string) (string, error)
 	IsRole(token string, authorityType int) (bool, error)
 	Close()
 }
 
-// getTokenExpire
-func getTokenExpire(loginType int) time.Duration {
+// maxTokenExpire returns the longest session lifetime of the login types,
+// it is how long a revocation of all the sessions of a user has to be kept.
+func maxTokenExpire() time.Duration {
+	max := time.Duration(0)
+	for _, loginType := range []int{LoginTypeWeb, LoginTypeWx, LoginTypeApp, LoginTypeDevice} {
+		if expire := GetTokenExpire(loginType); expire > max {
+			max = expire
+		}
+	}
+	return max
+}
+
+// GetTokenExpire returns the session lifetime of the login type.
+func GetTokenExpire(loginType int) time.Duration {
 	switch loginType {
 	case LoginTypeWeb:
 		return RedisSessionTimeoutWeb
@@ -174,3 +316,29 @@ func getTokenExpire(loginType int) time.Duration {
 func getUserPrefixKey(authorityType int, id string) string {
 	return fmt.Sprintf("%s%d_%s", GtSessionUserPrefix, authorityType, id)
 }
+
+// getUserIdPrefixKey
+func getUserIdPrefixKey(id string) string {
+	return GtSessionUserIdPrefix + id
+}
+
+// getTenancyPrefixKey
+func getTenancyPrefixKey(tenancyId uint) string {
+	return fmt.Sprintf("%s%d", GtSessionTenancyPrefix, tenancyId)
+}
+
+// getAuthorityPrefixKey
+func getAuthorityPrefixKey(authorityId string) string {
+	return GtSessionAuthorityPrefix + authorityId
+}
+
+// getAuthorityIds splits the joined authority id of claims.
+func getAuthorityIds(authorityId string) []string {
+	authorityIds := []string{}
+	for _, id := range strings.Split(authorityId, AuthorityTypeSplit) {
+		if id != "" {
+			authorityIds = append(authorityIds, id)
+		}
+	}
+	return authorityIds
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/snowlyg/multi"
+	multi_http "github.com/snowlyg/multi/nethttp"
+)
+
+// init 初始化认证驱动
+// 驱动类型： 可选 redis ,local,jwt
+func init() {
+	options := &redis.UniversalOptions{
+		Addrs:       []string{"127.0.0.1:6379"},
+		Password:    "",
+		PoolSize:    10,
+		IdleTimeout: 300 * time.Second,
+	}
+
+	err := multi.InitDriver(&multi.Config{
+		DriverType:      "redis",
+		TokenMaxCount:   10,
+		UniversalClient: redis.NewUniversalClient(options)})
+	if err != nil {
+		panic(fmt.Sprintf("auth is not init get err %v\n", err))
+	}
+}
+
+func auth() func(http.Handler) http.Handler {
+	verifier := multi_http.NewVerifier()
+	verifier.Extractors = []multi_http.TokenExtractor{multi_http.FromHeader} // extract token only from Authorization: Bearer $token
+	return verifier.Verify()
+}
+
+func main() {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/", generateToken)
+
+	// Register the verify middleware to allow access only to authorized clients.
+	verify := auth()
+	mux.Handle("/protected", verify(http.HandlerFunc(protected)))
+	// Invalidate the token through server-side, even if it's not expired yet.
+	mux.Handle("/protected/logout", verify(http.HandlerFunc(logout)))
+
+	// http://localhost:8080
+	// http://localhost:8080/protected (or Authorization: Bearer $token)
+	// http://localhost:8080/protected/logout
+	// http://localhost:8080/protected (401)
+	http.ListenAndServe(":8080", mux)
+}
+
+func generateToken(w http.ResponseWriter, r *http.Request) {
+	claims := &multi.MultiClaims{
+		Id:            "1",
+		Username:      "your name",
+		AuthorityId:   "your authority id",
+		TenancyId:     1,
+		TenancyName:   "your tenancy name",
+		AuthorityType: multi.AdminAuthority,
+		LoginType:     multi.LoginTypeWeb,
+		AuthType:      multi.AuthPwd,
+		CreationDate:  time.Now().Local().Unix(),
+		ExpiresAt:     time.Now().Local().Add(multi.RedisSessionTimeoutWeb).Unix(),
+	}
+
+	token, _, err := multi.AuthDriver.GenerateToken(claims)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Fprint(w, token)
+}
+
+func protected(w http.ResponseWriter, r *http.Request) {
+	claims := multi_http.Get(r.Context())
+	fmt.Fprintf(w, "claims=%+v\n", claims)
+}
+
+func logout(w http.ResponseWriter, r *http.Request) {
+	token := multi_http.GetVerifiedToken(r.Context())
+	if token == nil {
+		fmt.Fprint(w, "授权凭证为空")
+		return
+	}
+	err := multi.AuthDriver.DelUserTokenCache(string(token))
+	if err != nil {
+		fmt.Fprint(w, err.Error())
+		return
+	}
+	fmt.Fprint(w, "token invalidated, a new token is required to access the protected API")
+}
@@ -0,0 +1,72 @@
+package nethttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/snowlyg/multi"
+)
+
+// TokenExtractor is a function that takes a request as input and returns
+// a token. An empty string should be returned if no token found
+// without additional information.
+type TokenExtractor func(*http.Request) string
+
+// FromHeader is a token extractor.
+// It reads the token from the Authorization request header of form:
+// Authorization: "Bearer {token}".
+func FromHeader(r *http.Request) string {
+	return multi.ParseHeaderToken(r.Header.Get("Authorization"), "Bearer")
+}
+
+// FromQuery is a token extractor.
+// It reads the token from the "token" url query parameter.
+func FromQuery(r *http.Request) string {
+	return r.URL.Query().Get("token")
+}
+
+// FromJSON is a token extractor.
+// Reads a json request body and extracts the json based on the given field.
+// The request content-type should contain the: application/json header value, otherwise
+// this method will not try to read the body.
+// The body is restored after reading, so the handler can read it again.
+func FromJSON(jsonKey string) TokenExtractor {
+	return func(r *http.Request) string {
+		if r.Body == nil {
+			return ""
+		}
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+			return ""
+		}
+
+		body, err := io.ReadAll(r.Body)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			return ""
+		}
+
+		var m map[string]interface{}
+		if err := json.Unmarshal(body, &m); err != nil {
+			return ""
+		}
+
+		if m == nil {
+			return ""
+		}
+
+		v, ok := m[jsonKey]
+		if !ok {
+			return ""
+		}
+
+		tok, ok := v.(string)
+		if !ok {
+			return ""
+		}
+
+		return tok
+	}
+}
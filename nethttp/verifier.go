@@ -0,0 +1,203 @@
+package nethttp
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/snowlyg/multi"
+)
+
+type contextKey string
+
+const (
+	claimsContextKey        contextKey = "nethttp.multi.claims"
+	verifiedTokenContextKey contextKey = "nethttp.multi.token"
+)
+
+// Get returns the standard claims decoded by a verifier.
+func Get(ctx context.Context) *multi.MultiClaims {
+	if v, ok := ctx.Value(claimsContextKey).(multi.Claims); ok {
+		return v.Standard()
+	}
+	return nil
+}
+
+// GetClaims returns the custom claims decoded by a VerifierOf[T].
+func GetClaims[T multi.Claims](ctx context.Context) T {
+	var empty T
+	if v, ok := ctx.Value(claimsContextKey).(T); ok {
+		return v
+	}
+	return empty
+}
+
+// GetAuthorityType 角色类型
+func GetAuthorityType(ctx context.Context) int {
+	if v := Get(ctx); v != nil {
+		return v.AuthorityType
+	}
+	return 0
+}
+
+// GetAuthorityId 角色id
+func GetAuthorityId(ctx context.Context) []string {
+	return Get(ctx).AuthorityIds()
+}
+
+// GetUserId 用户id
+func GetUserId(ctx context.Context) uint {
+	return Get(ctx).UserId()
+}
+
+// GetUsername 用户名
+func GetUsername(ctx context.Context) string {
+	if v := Get(ctx); v != nil {
+		return v.Username
+	}
+	return ""
+}
+
+// GetTenancyId 商户id
+func GetTenancyId(ctx context.Context) uint {
+	if v := Get(ctx); v != nil {
+		return v.TenancyId
+	}
+	return 0
+}
+
+// GetTenancyName 商户名称
+func GetTenancyName(ctx context.Context) string {
+	if v := Get(ctx); v != nil {
+		return v.TenancyName
+	}
+	return ""
+}
+
+// GetCreationDate 登录时间
+func GetCreationDate(ctx context.Context) int64 {
+	if v := Get(ctx); v != nil {
+		return v.CreationDate
+	}
+	return 0
+}
+
+// GetExpiresIn 有效期
+func GetExpiresIn(ctx context.Context) int64 {
+	if v := Get(ctx); v != nil {
+		return v.ExpiresAt
+	}
+	return 0
+}
+
+func GetVerifiedToken(ctx context.Context) []byte {
+	if tok, ok := ctx.Value(verifiedTokenContextKey).([]byte); ok {
+		return tok
+	}
+	return nil
+}
+
+func IsRole(ctx context.Context, authorityType int) bool {
+	if v := Get(ctx); v != nil {
+		return v.AuthorityType == authorityType
+	}
+	return false
+}
+
+func IsAdmin(ctx context.Context) bool {
+	return IsRole(ctx, multi.AdminAuthority)
+}
+
+type Verifier = VerifierOf[*multi.MultiClaims]
+
+// VerifierOf verifies the token and stores the claims of type T.
+// The global multi.AuthDriver is used when Driver is nil.
+type VerifierOf[T multi.Claims] struct {
+	Driver       multi.AuthenticationOf[T]
+	Extractors   []TokenExtractor
+	Validators   []multi.TokenValidator
+	ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// DefaultErrorHandler writes the verification error as the problem+json response,
+// the WWW-Authenticate header is set for 401 responses.
+func DefaultErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	vErr := multi.AsVerifyError(err)
+	if challenge := vErr.WWWAuthenticate(); challenge != "" {
+		w.Header().Set("WWW-Authenticate", challenge)
+	}
+	body := vErr.ProblemJSON()
+	w.Header().Set("Content-Type", multi.ProblemContentType)
+	w.WriteHeader(vErr.Status)
+	w.Write(body)
+}
+
+func NewVerifier(validators ...multi.TokenValidator) *Verifier {
+	return NewVerifierOf[*multi.MultiClaims](nil, validators...)
+}
+
+func NewVerifierOf[T multi.Claims](driver multi.AuthenticationOf[T], validators ...multi.TokenValidator) *VerifierOf[T] {
+	return &VerifierOf[T]{
+		Driver:       driver,
+		Extractors:   []TokenExtractor{FromHeader, FromQuery},
+		ErrorHandler: DefaultErrorHandler,
+		Validators:   validators,
+	}
+}
+
+// RequestToken extracts the token from the request.
+func (v *VerifierOf[T]) RequestToken(r *http.Request) (token string) {
+	for _, extract := range v.Extractors {
+		if token = extract(r); token != "" {
+			break // ok we found it.
+		}
+	}
+	return
+}
+
+// VerifyToken verifies the token and returns its claims,
+// the error is a *multi.VerifyError which wraps the cause.
+// The multi.RequestValidators get an empty request, Verify passes the request to them.
+func (v *VerifierOf[T]) VerifyToken(token []byte, validators ...multi.TokenValidator) ([]byte, T, error) {
+	return v.verifyRequest(token, multi.RequestInfo{}, validators...)
+}
+
+// verifyRequest verifies the token by multi.VerifyRequest,
+// the validators of the verifier run before the given ones.
+func (v *VerifierOf[T]) verifyRequest(token []byte, req multi.RequestInfo, validators ...multi.TokenValidator) ([]byte, T, error) {
+	validators = append(append([]multi.TokenValidator{}, v.Validators...), validators...)
+	rcc, err := multi.VerifyRequest(v.Driver, token, req, validators...)
+	if err != nil {
+		return nil, rcc, err
+	}
+	return token, rcc, nil
+}
+
+func (v *VerifierOf[T]) Verify(validators ...multi.TokenValidator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := []byte(v.RequestToken(r))
+			verifiedToken, rcc, err := v.verifyRequest(token, requestInfo(r), validators...)
+			if err != nil {
+				v.ErrorHandler(w, r, err)
+				return
+			}
+			ctx := context.WithValue(r.Context(), claimsContextKey, rcc)
+			ctx = context.WithValue(ctx, verifiedTokenContextKey, verifiedToken)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// requestInfo returns the request metadata for the multi.RequestValidators.
+func requestInfo(r *http.Request) multi.RequestInfo {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return multi.RequestInfo{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		IP:     ip,
+	}
+}
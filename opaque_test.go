@@ -0,0 +1,75 @@
+package multi
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+var opaqueKey = bytes.Repeat([]byte("k"), 32)
+
+func TestSignedTokenGenerator(t *testing.T) {
+	g, err := NewSignedTokenGenerator(opaqueKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := g.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(token, OpaqueTokenPrefix) || len(token) != len(OpaqueTokenPrefix)+70 {
+		t.Fatalf("token want prefix %s and 70 chars but get %s", OpaqueTokenPrefix, token)
+	}
+	if err := g.CheckToken(token); err != nil {
+		t.Errorf("check token want nil but get %v", err)
+	}
+
+	other, _ := NewSignedTokenGenerator(bytes.Repeat([]byte("o"), 32))
+	forged, _ := other.Generate()
+	mistyped := []byte(token)
+	if mistyped[10] == 'A' {
+		mistyped[10] = 'B'
+	} else {
+		mistyped[10] = 'A'
+	}
+	for name, bad := range map[string]string{
+		"empty":    "",
+		"garbage":  "garbage",
+		"legacy":   tToken,
+		"prefix":   "mlt2_" + token[len(OpaqueTokenPrefix):],
+		"short":    token[:40],
+		"mistyped": string(mistyped),
+		"forged":   forged,
+	} {
+		if err := g.CheckToken(bad); !errors.Is(err, ErrTokenMalformed) {
+			t.Errorf("check %s token want %v but get %v", name, ErrTokenMalformed, err)
+		}
+	}
+	if err := g.ValidateToken([]byte(forged), nil); !errors.Is(err, ErrTokenMalformed) {
+		t.Errorf("validate forged token want %v but get %v", ErrTokenMalformed, err)
+	}
+	if code := AsVerifyError(g.CheckToken(forged)).Code; code != ErrCodeMalformed {
+		t.Errorf("verify error code want %s but get %s", ErrCodeMalformed, code)
+	}
+	if _, err := NewSignedTokenGenerator([]byte("short")); err == nil {
+		t.Error("short key want error")
+	}
+}
+
+func TestLocalSignedToken(t *testing.T) {
+	g, _ := NewSignedTokenGenerator(opaqueKey)
+	auth := NewLocalAuth()
+	auth.Generator = g
+	token, _, err := auth.GenerateToken(customClaims)
+	if err != nil {
+		t.Fatalf("generate token %v", err)
+	}
+	defer auth.DelUserTokenCache(token)
+	if _, err := auth.GetMultiClaims(token); err != nil {
+		t.Errorf("get custom claims %v", err)
+	}
+	if _, err := auth.GetMultiClaims(tToken); !errors.Is(err, ErrTokenMalformed) {
+		t.Errorf("get custom claims of legacy token want %v but get %v", ErrTokenMalformed, err)
+	}
+}
@@ -2,67 +2,117 @@ package multi
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/pkg/errors"
 )
 
+// claimsPayloadField keeps the json of custom claims besides the standard fields.
+const claimsPayloadField = "claims"
+
+// sealedClaimsField keeps the claims encrypted by the KeyRing instead of the standard fields.
+const sealedClaimsField = "sealed"
+
+// csrfSecretField keeps the csrf secret of the session besides the standard fields,
+// so it expires and is deleted with the session.
+const csrfSecretField = "csrf"
+
 // RedisAuth
-type RedisAuth struct {
-	Client redis.UniversalClient
+type RedisAuth = RedisAuthOf[*MultiClaims]
+
+// RedisAuthOf
+// Generator generates the tokens, DefaultTokenGenerator is used if it is nil.
+// Hasher hashes the tokens at rest, the tokens are stored verbatim if it is nil.
+// KeyRing encrypts the claims at rest, the claims are stored in plaintext if it is nil.
+// Clock times the events and the validation of the claims, the redis server keeps the session expiry by its own time.
+// Events receives the session lifecycle events, if it is set.
+type RedisAuthOf[T Claims] struct {
+	Client    redis.UniversalClient
+	Clock     Clock
+	Generator TokenGenerator
+	Hasher    *TokenHasher
+	KeyRing   *KeyRing
+	Events    EventDispatcher
 }
 
 // NewRedisAuth
 func NewRedisAuth(client redis.UniversalClient) (*RedisAuth, error) {
+	return NewRedisAuthOf[*MultiClaims](client)
+}
+
+// NewRedisAuthOf
+func NewRedisAuthOf[T Claims](client redis.UniversalClient) (*RedisAuthOf[T], error) {
+	if client == nil {
+		return nil, errors.New("redis client is nil")
+	}
 	_, err := client.Ping(context.Background()).Result()
 	if err != nil {
 		return nil, err
 	}
-	if client == nil {
-		return nil, errors.New("redis client is nil")
-	}
-	return &RedisAuth{
+	return &RedisAuthOf[T]{
 		Client: client,
 	}, nil
 }
 
 // GenerateToken
-func (ra *RedisAuth) GenerateToken(claims *MultiClaims) (string, int64, error) {
-	token, err := ra.GetTokenByClaims(claims)
-	if err != nil {
-		return "", int64(claims.ExpiresAt), err
+func (ra *RedisAuthOf[T]) GenerateToken(claims T) (string, int64, error) {
+	std := claims.Standard()
+	var token string
+	var err error
+	// the session of the equal claims is reused, but a hashed token can not be recovered,
+	// so a new session is issued besides the existing ones with a Hasher.
+	if ra.Hasher == nil {
+		if token, err = ra.GetTokenByClaims(claims); err != nil {
+			return "", int64(std.ExpiresAt), err
+		}
 	}
 
 	if token == "" {
-		if isOver, err := ra.isUserTokenOver(claims.AuthorityType, claims.Id); err != nil {
-			return "", int64(claims.ExpiresAt), err
+		if isOver, err := ra.isUserTokenOver(std.AuthorityType, std.Id); err != nil {
+			return "", int64(std.ExpiresAt), err
 		} else if isOver {
-			return "", int64(claims.ExpiresAt), ErrOverMaxTokenCount
+			dispatch(ra.Events, ra.Clock, Event{Type: EventLimitExceeded, Claims: std, Err: ErrOverMaxTokenCount})
+			return "", int64(std.ExpiresAt), ErrOverMaxTokenCount
 		}
 
-		token, err = GetToken()
+		token, err = generatorOf(ra.Generator).Generate()
 		if err != nil {
-			return "", int64(claims.ExpiresAt), err
+			return "", int64(std.ExpiresAt), err
 		}
 	}
 
-	err = ra.toCache(token, claims)
+	id := hashToken(ra.Hasher, token)
+	err = ra.toCache(id, claims)
 	if err != nil {
-		return "", int64(claims.ExpiresAt), err
+		return "", int64(std.ExpiresAt), err
 	}
 
-	if err = ra.syncUserTokenCache(token); err != nil {
-		return "", int64(claims.ExpiresAt), err
+	if err = ra.syncUserTokenCache(id); err != nil {
+		return "", int64(std.ExpiresAt), err
 	}
 
-	return token, int64(claims.ExpiresAt), nil
+	dispatch(ra.Events, ra.Clock, Event{Type: EventLogin, Token: id, Claims: std})
+	return token, int64(std.ExpiresAt), nil
+}
+
+// clock
+func (ra *RedisAuthOf[T]) clock() Clock {
+	return clockOf(ra.Clock)
+}
+
+// sessionId returns the id of the session of the token, see WatchRevoked.
+func (ra *RedisAuthOf[T]) sessionId(token string) string {
+	return hashToken(ra.Hasher, token)
 }
 
 // toCache
-func (ra *RedisAuth) toCache(token string, cla *MultiClaims) error {
-	sKey := GtSessionTokenPrefix + token
-	if _, err := ra.Client.HMSet(context.Background(), sKey,
+func (ra *RedisAuthOf[T]) toCache(id string, claims T) error {
+	sKey := GtSessionTokenPrefix + id
+	cla := claims.Standard()
+	values := []interface{}{
 		"id", cla.Id,
 		"login_type", cla.LoginType,
 		"auth_type", cla.AuthType,
@@ -71,12 +121,39 @@ func (ra *RedisAuth) toCache(token string, cla *MultiClaims) error {
 		"tenancy_name", cla.TenancyName,
 		"authority_id", cla.AuthorityId,
 		"authority_type", cla.AuthorityType,
+		"issuer", cla.Issuer,
 		"creation_data", cla.CreationDate,
 		"expires_at", cla.ExpiresAt,
-	).Result(); err != nil {
+	}
+	if _, ok := any(claims).(*MultiClaims); !ok {
+		payload, err := json.Marshal(claims)
+		if err != nil {
+			return fmt.Errorf("to cache token json marshal %w", err)
+		}
+		values = append(values, claimsPayloadField, payload)
+	}
+	// the fields of the other format are removed, e.g. when a plaintext session is sealed.
+	staleFields := []string{sealedClaimsField}
+	if ra.KeyRing != nil {
+		sealed, err := ra.sealClaims(sKey, claims)
+		if err != nil {
+			return err
+		}
+		staleFields = []string{claimsPayloadField}
+		for i := 0; i < len(values); i += 2 {
+			staleFields = append(staleFields, values[i].(string))
+		}
+		values = []interface{}{sealedClaimsField, sealed}
+	}
+	_, err := ra.Client.TxPipelined(context.Background(), func(pipe redis.Pipeliner) error {
+		pipe.HDel(context.Background(), sKey, staleFields...)
+		pipe.HMSet(context.Background(), sKey, values...)
+		return nil
+	})
+	if err != nil {
 		return fmt.Errorf("to cache token %w", err)
 	}
-	err := ra.setExpire(sKey, cla.LoginType)
+	err = ra.setExpire(sKey, cla.LoginType)
 	if err != nil {
 		return err
 	}
@@ -84,8 +161,33 @@ func (ra *RedisAuth) toCache(token string, cla *MultiClaims) error {
 	return nil
 }
 
-// GetTokenByClaims
-func (ra *RedisAuth) GetTokenByClaims(cla *MultiClaims) (string, error) {
+// sealClaims encrypts the json of the claims, it is bound to the session key.
+func (ra *RedisAuthOf[T]) sealClaims(sKey string, claims T) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("seal claims json marshal %w", err)
+	}
+	return ra.KeyRing.Seal(payload, []byte(sKey))
+}
+
+// openClaims decrypts the sealed json of the claims.
+func (ra *RedisAuthOf[T]) openClaims(sKey, sealed string, claims T) error {
+	if ra.KeyRing == nil {
+		return fmt.Errorf("%w: no key ring", ErrClaimsUndecryptable)
+	}
+	payload, err := ra.KeyRing.Open(sealed, []byte(sKey))
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return internal(fmt.Errorf("open claims json unmarshal %w", err))
+	}
+	return nil
+}
+
+// GetTokenByClaims returns the token of the session of the claims, or its hash with a Hasher.
+func (ra *RedisAuthOf[T]) GetTokenByClaims(claims T) (string, error) {
+	cla := claims.Standard()
 	userTokens, err := ra.getUserTokens(cla.AuthorityType, cla.Id)
 	if err != nil {
 		return "", err
@@ -94,7 +196,8 @@ func (ra *RedisAuth) GetTokenByClaims(cla *MultiClaims) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	for token, existCla := range clas {
+	for token, exist := range clas {
+		existCla := exist.Standard()
 		if cla.AuthType == existCla.AuthType &&
 			cla.Id == existCla.Id &&
 			cla.AuthorityType == existCla.AuthorityType &&
@@ -108,35 +211,120 @@ func (ra *RedisAuth) GetTokenByClaims(cla *MultiClaims) (string, error) {
 }
 
 // getMultiClaimses
-func (ra *RedisAuth) getMultiClaimses(tokens []string) (map[string]*MultiClaims, error) {
-	clas := make(map[string]*MultiClaims, ra.getUserTokenMaxCount())
-	for _, token := range tokens {
-		cla, err := ra.GetMultiClaims(token)
+func (ra *RedisAuthOf[T]) getMultiClaimses(ids []string) (map[string]T, error) {
+	clas := make(map[string]T, ra.getUserTokenMaxCount())
+	for _, id := range ids {
+		cla, err := ra.getMultiClaims(id)
 		if err != nil {
 			continue
 		}
-		clas[token] = cla
+		clas[id] = cla
 	}
 
 	return clas, nil
 }
 
 // GetMultiClaims
-func (ra *RedisAuth) GetMultiClaims(token string) (*MultiClaims, error) {
-	cla := new(MultiClaims)
-	if err := ra.Client.HGetAll(context.Background(), GtSessionTokenPrefix+token).Scan(cla); err != nil {
-		return nil, fmt.Errorf("get custom claims redis hgetall %w", err)
+func (ra *RedisAuthOf[T]) GetMultiClaims(token string) (T, error) {
+	claims, err := ra.findMultiClaims(token)
+	if err != nil {
+		ra.verifyFailed(token, RequestInfo{}, err)
 	}
+	return claims, err
+}
 
-	if cla == nil || cla.Id == "" {
-		return nil, ErrEmptyToken
+// verifyFailed dispatches the EventVerifyFailed event of the token.
+func (ra *RedisAuthOf[T]) verifyFailed(token string, req RequestInfo, err error) {
+	dispatch(ra.Events, ra.Clock, Event{Type: EventVerifyFailed, Token: hashToken(ra.Hasher, token), Request: req, Err: err})
+}
+
+// findMultiClaims returns the claims of the token, the legacy session is migrated first.
+func (ra *RedisAuthOf[T]) findMultiClaims(token string) (T, error) {
+	var empty T
+	if err := checkToken(ra.Generator, token); err != nil {
+		return empty, err
+	}
+	id := hashToken(ra.Hasher, token)
+	claims, err := ra.getMultiClaims(id)
+	if errors.Is(err, ErrTokenInvalid) && ra.Hasher != nil && ra.Hasher.MigrateLegacy {
+		if err = ra.migrateLegacy(token, id); err != nil {
+			return empty, err
+		}
+		return ra.getMultiClaims(id)
 	}
+	return claims, err
+}
 
-	return cla, nil
+// migrateLegacy moves the session stored by the plain token to the hashed keys,
+// ErrTokenInvalid is returned if there is no such session.
+func (ra *RedisAuthOf[T]) migrateLegacy(token, id string) error {
+	claims, err := ra.getMultiClaims(token)
+	if err != nil {
+		return err
+	}
+	sKey := GtSessionTokenPrefix + token
+	ttl, err := ra.Client.PTTL(context.Background(), sKey).Result()
+	if err != nil {
+		return internal(fmt.Errorf("migrate legacy token redis pttl %w", err))
+	}
+	if ttl <= 0 {
+		ttl = GetTokenExpire(claims.Standard().LoginType)
+	}
+	// the claims are stored again as the sealed ones are bound to the session key.
+	if err = ra.toCache(id, claims); err != nil {
+		return internal(err)
+	}
+	if secret, err := ra.Client.HGet(context.Background(), sKey, csrfSecretField).Result(); err == nil {
+		if _, err = ra.Client.HSet(context.Background(), GtSessionTokenPrefix+id, csrfSecretField, secret).Result(); err != nil {
+			return internal(fmt.Errorf("migrate legacy token redis hset %w", err))
+		}
+	}
+	if _, err = ra.Client.PExpire(context.Background(), GtSessionTokenPrefix+id, ttl).Result(); err != nil {
+		return internal(fmt.Errorf("migrate legacy token redis pexpire %w", err))
+	}
+	if err = ra.delSessionIndex(claims.Standard(), token); err != nil {
+		return internal(err)
+	}
+	if err = ra.syncUserTokenCache(id); err != nil {
+		return internal(err)
+	}
+	if _, err = ra.Client.Del(context.Background(), sKey, GtSessionBindUserPrefix+token).Result(); err != nil {
+		return internal(fmt.Errorf("migrate legacy token redis del %w", err))
+	}
+	return nil
+}
+
+// getMultiClaims returns the claims of the session id.
+func (ra *RedisAuthOf[T]) getMultiClaims(id string) (T, error) {
+	var empty T
+	claims := newClaims[T]()
+	sKey := GtSessionTokenPrefix + id
+	cmd := ra.Client.HGetAll(context.Background(), sKey)
+	if sealed, ok := cmd.Val()[sealedClaimsField]; ok {
+		if err := ra.openClaims(sKey, sealed, claims); err != nil {
+			return empty, err
+		}
+	} else {
+		if err := cmd.Scan(claims.Standard()); err != nil {
+			return empty, internal(fmt.Errorf("get custom claims redis hgetall %w", err))
+		}
+		if payload, ok := cmd.Val()[claimsPayloadField]; ok {
+			if err := json.Unmarshal([]byte(payload), claims); err != nil {
+				return empty, internal(fmt.Errorf("get custom claims json unmarshal %w", err))
+			}
+		}
+	}
+
+	// the session is unknown, expired or revoked.
+	if claims.Standard().Id == "" {
+		return empty, ErrTokenInvalid
+	}
+
+	return claims, nil
 }
 
 // isUserTokenOver
-func (ra *RedisAuth) isUserTokenOver(authorityType int, userId string) (bool, error) {
+func (ra *RedisAuthOf[T]) isUserTokenOver(authorityType int, userId string) (bool, error) {
 	max, err := ra.getUserTokenCount(authorityType, userId)
 	if err != nil {
 		return true, err
@@ -145,7 +333,7 @@ func (ra *RedisAuth) isUserTokenOver(authorityType int, userId string) (bool, er
 }
 
 // getUserTokens
-func (ra *RedisAuth) getUserTokens(authorityType int, userId string) ([]string, error) {
+func (ra *RedisAuthOf[T]) getUserTokens(authorityType int, userId string) ([]string, error) {
 	userTokens, err := ra.Client.SMembers(context.Background(), getUserPrefixKey(authorityType, userId)).Result()
 	if err != nil {
 		return nil, fmt.Errorf("get user token count menbers  %w", err)
@@ -154,7 +342,7 @@ func (ra *RedisAuth) getUserTokens(authorityType int, userId string) ([]string,
 }
 
 // getUserTokenCount
-func (ra *RedisAuth) getUserTokenCount(authorityType int, userId string) (int64, error) {
+func (ra *RedisAuthOf[T]) getUserTokenCount(authorityType int, userId string) (int64, error) {
 	var count int64
 	userTokens, err := ra.getUserTokens(authorityType, userId)
 	if err != nil {
@@ -164,13 +352,16 @@ func (ra *RedisAuth) getUserTokenCount(authorityType int, userId string) (int64,
 	for _, token := range userTokens {
 		if ra.checkUserTokenCount(token, userPrefixKey) == 1 {
 			count++
+		} else {
+			// the user index is checked on every login, so the expired session is reported once.
+			dispatch(ra.Events, ra.Clock, Event{Type: EventRevoke, Token: token, Reason: RevokeReasonExpired})
 		}
 	}
 	return count, nil
 }
 
 // checkUserTokenCount
-func (ra *RedisAuth) checkUserTokenCount(token, userPrefixKey string) int64 {
+func (ra *RedisAuthOf[T]) checkUserTokenCount(token, userPrefixKey string) int64 {
 	mun, err := ra.Client.Exists(context.Background(), GtSessionTokenPrefix+token).Result()
 	if err != nil || mun == 0 {
 		ra.Client.SRem(context.Background(), userPrefixKey, token)
@@ -179,7 +370,7 @@ func (ra *RedisAuth) checkUserTokenCount(token, userPrefixKey string) int64 {
 }
 
 // getUserTokenMaxCount
-func (ra *RedisAuth) getUserTokenMaxCount() int64 {
+func (ra *RedisAuthOf[T]) getUserTokenMaxCount() int64 {
 	count, err := ra.Client.Get(context.Background(), GtSessionUserMaxTokenPrefix).Int64()
 	if err != nil {
 		return GtSessionUserMaxTokenDefault
@@ -188,7 +379,7 @@ func (ra *RedisAuth) getUserTokenMaxCount() int64 {
 }
 
 // SetUserTokenMaxCount
-func (ra *RedisAuth) SetUserTokenMaxCount(tokenMaxCount int64) error {
+func (ra *RedisAuthOf[T]) SetUserTokenMaxCount(tokenMaxCount int64) error {
 	err := ra.Client.Set(context.Background(), GtSessionUserMaxTokenPrefix, tokenMaxCount, 0).Err()
 	if err != nil {
 		return err
@@ -197,76 +388,176 @@ func (ra *RedisAuth) SetUserTokenMaxCount(tokenMaxCount int64) error {
 }
 
 // syncUserTokenCache
-func (ra *RedisAuth) syncUserTokenCache(token string) error {
-	cla, err := ra.GetMultiClaims(token)
+func (ra *RedisAuthOf[T]) syncUserTokenCache(id string) error {
+	claims, err := ra.getMultiClaims(id)
 	if err != nil {
 		return fmt.Errorf("sysnc user token cache %w", err)
 	}
-	userPrefixKey := getUserPrefixKey(cla.AuthorityType, cla.Id)
-	if _, err := ra.Client.SAdd(context.Background(), userPrefixKey, token).Result(); err != nil {
-		return fmt.Errorf("sync user token cache redis sadd %w", err)
+	cla := claims.Standard()
+	if err = ra.addSessionIndex(cla, id); err != nil {
+		return err
 	}
 
-	bindUserPrefixKey := GtSessionBindUserPrefix + token
-	_, err = ra.Client.Set(context.Background(), bindUserPrefixKey, userPrefixKey, getTokenExpire(cla.LoginType)).Result()
+	bindUserPrefixKey := GtSessionBindUserPrefix + id
+	_, err = ra.Client.Set(context.Background(), bindUserPrefixKey, getUserPrefixKey(cla.AuthorityType, cla.Id), GetTokenExpire(cla.LoginType)).Result()
 	if err != nil {
 		return fmt.Errorf("sync user token cache %w", err)
 	}
 	return nil
 }
 
+// addSessionIndex adds the session id to the user, tenancy and authority indexes.
+func (ra *RedisAuthOf[T]) addSessionIndex(cla *MultiClaims, id string) error {
+	if _, err := ra.Client.SAdd(context.Background(), getUserPrefixKey(cla.AuthorityType, cla.Id), id).Result(); err != nil {
+		return fmt.Errorf("sync user token cache redis sadd %w", err)
+	}
+
+	if _, err := ra.Client.SAdd(context.Background(), getUserIdPrefixKey(cla.Id), id).Result(); err != nil {
+		return fmt.Errorf("sync user id token cache redis sadd %w", err)
+	}
+	if cla.TenancyId > 0 {
+		if _, err := ra.Client.SAdd(context.Background(), getTenancyPrefixKey(cla.TenancyId), id).Result(); err != nil {
+			return fmt.Errorf("sync tenancy token cache redis sadd %w", err)
+		}
+	}
+	for _, authorityId := range getAuthorityIds(cla.AuthorityId) {
+		if _, err := ra.Client.SAdd(context.Background(), getAuthorityPrefixKey(authorityId), id).Result(); err != nil {
+			return fmt.Errorf("sync authority token cache redis sadd %w", err)
+		}
+	}
+	return nil
+}
+
+// BackfillSessionIndexes adds the existing sessions to the user id, tenancy and authority indexes,
+// run it once after upgrading, as the sessions created before these indexes are only found
+// by CleanUserTokenCache. It scans the keys of the connected node, so run it on every master of a cluster.
+func (ra *RedisAuthOf[T]) BackfillSessionIndexes(ctx context.Context) error {
+	iter := ra.Client.Scan(ctx, 0, GtSessionTokenPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		id := strings.TrimPrefix(iter.Val(), GtSessionTokenPrefix)
+		claims, err := ra.getMultiClaims(id)
+		if err != nil {
+			continue
+		}
+		if err = ra.addSessionIndex(claims.Standard(), id); err != nil {
+			return err
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return fmt.Errorf("backfill session indexes redis scan %w", err)
+	}
+	return nil
+}
+
 // UpdateUserTokenCacheExpire
-func (ra *RedisAuth) UpdateUserTokenCacheExpire(token string) error {
-	rcc, err := ra.GetMultiClaims(token)
+func (ra *RedisAuthOf[T]) UpdateUserTokenCacheExpire(token string) error {
+	claims, err := ra.GetMultiClaims(token)
 	if err != nil {
 		return fmt.Errorf("update user token cache expire %w", err)
 	}
+	rcc := claims.Standard()
 	if rcc == nil {
 		return errors.New("token cache is nil")
 	}
-	if err = ra.setExpire(GtSessionTokenPrefix+token, rcc.LoginType); err != nil {
+	id := hashToken(ra.Hasher, token)
+	if err = ra.rotateKey(id, claims); err != nil {
+		return fmt.Errorf("update user token cache expire %w", err)
+	}
+	if err = ra.setExpire(GtSessionTokenPrefix+id, rcc.LoginType); err != nil {
 		return fmt.Errorf("update user token cache expire redis expire %w", err)
 	}
-	if err = ra.setExpire(GtSessionBindUserPrefix+token, rcc.LoginType); err != nil {
+	if err = ra.setExpire(GtSessionBindUserPrefix+id, rcc.LoginType); err != nil {
 		return fmt.Errorf("update user token cache expire redis expire %w", err)
 	}
+	dispatch(ra.Events, ra.Clock, Event{Type: EventRenew, Token: id, Claims: rcc})
 	return nil
 }
 
-func (ra *RedisAuth) setExpire(key string, loginType int) error {
-	if _, err := ra.Client.Expire(context.Background(), key, getTokenExpire(loginType)).Result(); err != nil {
+// rotateKey seals the claims again by the current key of the KeyRing
+// if they are in plaintext or sealed by a retired key.
+func (ra *RedisAuthOf[T]) rotateKey(id string, claims T) error {
+	if ra.KeyRing == nil {
+		return nil
+	}
+	sealed, err := ra.Client.HGet(context.Background(), GtSessionTokenPrefix+id, sealedClaimsField).Result()
+	if err != nil && err != redis.Nil {
+		return fmt.Errorf("rotate key redis hget %w", err)
+	}
+	if err == nil && !ra.KeyRing.NeedsRotation(sealed) {
+		return nil
+	}
+	return ra.toCache(id, claims)
+}
+
+func (ra *RedisAuthOf[T]) setExpire(key string, loginType int) error {
+	if _, err := ra.Client.Expire(context.Background(), key, GetTokenExpire(loginType)).Result(); err != nil {
 		return fmt.Errorf("update user token cache expire redis expire %w", err)
 	}
 	return nil
 }
 
 // DelUserTokenCache
-func (ra *RedisAuth) DelUserTokenCache(token string) error {
-	cla, err := ra.GetMultiClaims(token)
+func (ra *RedisAuthOf[T]) DelUserTokenCache(token string) error {
+	// GetMultiClaims moves the legacy session to the hashed keys first.
+	if _, err := ra.GetMultiClaims(token); err != nil {
+		return err
+	}
+	id := hashToken(ra.Hasher, token)
+	cla, err := ra.delSession(id)
 	if err != nil {
 		return err
 	}
+	dispatch(ra.Events, ra.Clock, Event{Type: EventLogout, Token: id, Claims: cla})
+	return nil
+}
+
+// delSession removes the session of the id and its indexes, the claims of the session are returned.
+func (ra *RedisAuthOf[T]) delSession(id string) (*MultiClaims, error) {
+	claims, err := ra.getMultiClaims(id)
+	if err != nil {
+		return nil, err
+	}
+	cla := claims.Standard()
 	if cla == nil {
-		return errors.New("del user token, reids cache is nil")
+		return nil, errors.New("del user token, reids cache is nil")
 	}
 
-	err = ra.delUserTokenPrefixToken(cla.AuthorityType, cla.Id, token)
+	err = ra.delSessionIndex(cla, id)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	err = ra.delTokenCache(token)
+	err = ra.delTokenCache(id)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return cla, nil
+}
+
+// delSessionIndex removes the token from the user, tenancy and authority indexes.
+func (ra *RedisAuthOf[T]) delSessionIndex(cla *MultiClaims, token string) error {
+	err := ra.delUserTokenPrefixToken(cla.AuthorityType, cla.Id, token)
 	if err != nil {
 		return err
 	}
+	if err = ra.delIndexToken(getUserIdPrefixKey(cla.Id), token); err != nil {
+		return err
+	}
+	if cla.TenancyId > 0 {
+		if err = ra.delIndexToken(getTenancyPrefixKey(cla.TenancyId), token); err != nil {
+			return err
+		}
+	}
+	for _, authorityId := range getAuthorityIds(cla.AuthorityId) {
+		if err = ra.delIndexToken(getAuthorityPrefixKey(authorityId), token); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 // delUserTokenPrefixToken
-func (ra *RedisAuth) delUserTokenPrefixToken(authorityType int, id, token string) error {
+func (ra *RedisAuthOf[T]) delUserTokenPrefixToken(authorityType int, id, token string) error {
 	_, err := ra.Client.SRem(context.Background(), getUserPrefixKey(authorityType, id), token).Result()
 	if err != nil {
 		return fmt.Errorf("del user token cache redis srem %w", err)
@@ -274,8 +565,17 @@ func (ra *RedisAuth) delUserTokenPrefixToken(authorityType int, id, token string
 	return nil
 }
 
+// delIndexToken
+func (ra *RedisAuthOf[T]) delIndexToken(indexKey, token string) error {
+	_, err := ra.Client.SRem(context.Background(), indexKey, token).Result()
+	if err != nil {
+		return fmt.Errorf("del index token cache redis srem %w", err)
+	}
+	return nil
+}
+
 // delTokenCache
-func (ra *RedisAuth) delTokenCache(token string) error {
+func (ra *RedisAuthOf[T]) delTokenCache(token string) error {
 	sKey2 := GtSessionBindUserPrefix + token
 	_, err := ra.Client.Del(context.Background(), sKey2).Result()
 	if err != nil {
@@ -288,11 +588,39 @@ func (ra *RedisAuth) delTokenCache(token string) error {
 		return fmt.Errorf("del user token cache redis del3  %w", err)
 	}
 
+	DefaultRevokeWatcher.Notify(token)
+	_, err = ra.Client.Publish(context.Background(), GtSessionRevokeChannel, token).Result()
+	if err != nil {
+		return fmt.Errorf("del user token cache redis publish  %w", err)
+	}
+
 	return nil
 }
 
+// SubscribeRevoked notifies DefaultRevokeWatcher of the tokens revoked by other instances,
+// it blocks until the ctx is done.
+func (ra *RedisAuthOf[T]) SubscribeRevoked(ctx context.Context) error {
+	pubsub := ra.Client.Subscribe(ctx, GtSessionRevokeChannel)
+	defer pubsub.Close()
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return fmt.Errorf("subscribe revoked redis subscribe %w", err)
+	}
+	ch := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			DefaultRevokeWatcher.Notify(msg.Payload)
+		}
+	}
+}
+
 // CleanUserTokenCache
-func (ra *RedisAuth) CleanUserTokenCache(authorityType int, userId string) error {
+func (ra *RedisAuthOf[T]) CleanUserTokenCache(authorityType int, userId string) error {
 	allTokens, err := ra.getUserTokens(authorityType, userId)
 	if err != nil {
 		return fmt.Errorf("clean user token cache redis smembers  %w", err)
@@ -303,24 +631,163 @@ func (ra *RedisAuth) CleanUserTokenCache(authorityType int, userId string) error
 	}
 
 	for _, token := range allTokens {
+		var cla *MultiClaims
+		if ra.Events != nil {
+			cla = standardOf(ra.getMultiClaims(token))
+		}
 		err = ra.delTokenCache(token)
 		if err != nil {
 			return err
 		}
+		dispatch(ra.Events, ra.Clock, Event{Type: EventRevoke, Token: token, Claims: cla, Reason: RevokeReasonCleaned})
 	}
 	return nil
 }
 
+// getIndexTokens returns the tokens of the index, expired ones are removed from the index.
+func (ra *RedisAuthOf[T]) getIndexTokens(indexKey string) ([]string, error) {
+	allTokens, err := ra.Client.SMembers(context.Background(), indexKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("get index tokens redis smembers %w", err)
+	}
+	indexTokens := make([]string, 0, len(allTokens))
+	for _, token := range allTokens {
+		if ra.checkUserTokenCount(token, indexKey) == 1 {
+			indexTokens = append(indexTokens, token)
+		}
+	}
+	return indexTokens, nil
+}
+
+// cleanIndexTokenCache removes every session of the index.
+func (ra *RedisAuthOf[T]) cleanIndexTokenCache(indexKey string) error {
+	indexTokens, err := ra.getIndexTokens(indexKey)
+	if err != nil {
+		return err
+	}
+	clas, err := ra.getMultiClaimses(indexTokens)
+	if err != nil {
+		return err
+	}
+	for token, claims := range clas {
+		err = ra.delSessionIndex(claims.Standard(), token)
+		if err != nil {
+			return err
+		}
+		err = ra.delTokenCache(token)
+		if err != nil {
+			return err
+		}
+		dispatch(ra.Events, ra.Clock, Event{Type: EventRevoke, Token: token, Claims: claims.Standard(), Reason: RevokeReasonCleaned})
+	}
+	_, err = ra.Client.Del(context.Background(), indexKey).Result()
+	if err != nil {
+		return fmt.Errorf("clean index token cache redis del %w", err)
+	}
+	return nil
+}
+
+// CleanAllUserTokenCache removes every session of the user whatever the authority type is,
+// the sessions created before the user id index are found by the index of every authority type.
+func (ra *RedisAuthOf[T]) CleanAllUserTokenCache(userId string) error {
+	if err := ra.cleanIndexTokenCache(getUserIdPrefixKey(userId)); err != nil {
+		return fmt.Errorf("clean all user token cache %w", err)
+	}
+	for _, authorityType := range []int{NoneAuthority, AdminAuthority, TenancyAuthority, GeneralAuthority} {
+		if err := ra.CleanUserTokenCache(authorityType, userId); err != nil {
+			return fmt.Errorf("clean all user token cache %w", err)
+		}
+	}
+	return nil
+}
+
+// GetTenancyTokens returns the sessions of the tenancy by token, or by its hash with a Hasher.
+func (ra *RedisAuthOf[T]) GetTenancyTokens(tenancyId uint) (map[string]T, error) {
+	tenancyTokens, err := ra.getIndexTokens(getTenancyPrefixKey(tenancyId))
+	if err != nil {
+		return nil, err
+	}
+	return ra.getMultiClaimses(tenancyTokens)
+}
+
+// GetTenancyTokenCount
+func (ra *RedisAuthOf[T]) GetTenancyTokenCount(tenancyId uint) (int64, error) {
+	tenancyTokens, err := ra.getIndexTokens(getTenancyPrefixKey(tenancyId))
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(tenancyTokens)), nil
+}
+
+// CleanTenancyTokenCache removes every session of the tenancy,
+// the sessions created before the tenancy index are only found after BackfillSessionIndexes.
+func (ra *RedisAuthOf[T]) CleanTenancyTokenCache(tenancyId uint) error {
+	if err := ra.cleanIndexTokenCache(getTenancyPrefixKey(tenancyId)); err != nil {
+		return fmt.Errorf("clean tenancy token cache %w", err)
+	}
+	return nil
+}
+
+// GetAuthorityTokens returns the sessions which hold the authority id by token, or by its hash with a Hasher.
+func (ra *RedisAuthOf[T]) GetAuthorityTokens(authorityId string) (map[string]T, error) {
+	authorityTokens, err := ra.getIndexTokens(getAuthorityPrefixKey(authorityId))
+	if err != nil {
+		return nil, err
+	}
+	return ra.getMultiClaimses(authorityTokens)
+}
+
+// CleanAuthorityTokenCache removes every session which holds the authority id,
+// the sessions created before the authority index are only found after BackfillSessionIndexes.
+func (ra *RedisAuthOf[T]) CleanAuthorityTokenCache(authorityId string) error {
+	if err := ra.cleanIndexTokenCache(getAuthorityPrefixKey(authorityId)); err != nil {
+		return fmt.Errorf("clean authority token cache %w", err)
+	}
+	return nil
+}
+
+// SetCsrfSecret generates a new csrf secret and binds it to the session of the token.
+func (ra *RedisAuthOf[T]) SetCsrfSecret(token string) (string, error) {
+	sKey := GtSessionTokenPrefix + hashToken(ra.Hasher, token)
+	count, err := ra.Client.Exists(context.Background(), sKey).Result()
+	if err != nil {
+		return "", fmt.Errorf("set csrf secret redis exists %w", err)
+	}
+	if count == 0 {
+		return "", ErrTokenInvalid
+	}
+	secret, err := GetToken()
+	if err != nil {
+		return "", err
+	}
+	if _, err = ra.Client.HSet(context.Background(), sKey, csrfSecretField, secret).Result(); err != nil {
+		return "", fmt.Errorf("set csrf secret redis hset %w", err)
+	}
+	return secret, nil
+}
+
+// GetCsrfSecret returns the csrf secret bound to the session of the token.
+func (ra *RedisAuthOf[T]) GetCsrfSecret(token string) (string, error) {
+	secret, err := ra.Client.HGet(context.Background(), GtSessionTokenPrefix+hashToken(ra.Hasher, token), csrfSecretField).Result()
+	if err == redis.Nil {
+		return "", ErrCsrfInvalid
+	}
+	if err != nil {
+		return "", fmt.Errorf("get csrf secret redis hget %w", err)
+	}
+	return secret, nil
+}
+
 // IsRole
-func (ra *RedisAuth) IsRole(token string, authorityType int) (bool, error) {
+func (ra *RedisAuthOf[T]) IsRole(token string, authorityType int) (bool, error) {
 	rcc, err := ra.GetMultiClaims(token)
 	if err != nil {
 		return false, fmt.Errorf("get User's infomation return error: %w", err)
 	}
-	return rcc.AuthorityType == authorityType, nil
+	return rcc.Standard().AuthorityType == authorityType, nil
 }
 
 // Close
-func (ra *RedisAuth) Close() {
+func (ra *RedisAuthOf[T]) Close() {
 	ra.Client.Close()
 }
@@ -204,8 +204,8 @@ func TestRedisDelUserTokenCache(t *testing.T) {
 			t.Fatalf("del user token cache  %v", err)
 		}
 		_, err = redisAuth.GetMultiClaims(token)
-		if !errors.Is(err, ErrEmptyToken) {
-			t.Fatalf("get custom claims err want '%v' but get  '%v'", ErrEmptyToken, err)
+		if !errors.Is(err, ErrTokenInvalid) {
+			t.Fatalf("get custom claims err want '%v' but get  '%v'", ErrTokenInvalid, err)
 		}
 
 		if uTokens, err := redisAuth.Client.SMembers(context.Background(), GtSessionUserPrefix+cc.Id).Result(); err != nil {
@@ -505,3 +505,245 @@ func TestRedisGetMultiClaimses(t *testing.T) {
 	})
 
 }
+
+func TestRedisAuthOfCustomClaims(t *testing.T) {
+	auth, err := NewRedisAuthOf[*avatarClaims](redis.NewUniversalClient(options))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	claims := newAvatarTestClaims(121322)
+	defer auth.CleanUserTokenCache(claims.AuthorityType, claims.Id)
+	t.Run("test custom claims", func(t *testing.T) {
+		token, _, err := auth.GenerateToken(claims)
+		if err != nil {
+			t.Fatalf("generate token %v", err)
+		}
+		cc, err := auth.GetMultiClaims(token)
+		if err != nil {
+			t.Fatalf("get custom claims %v", err)
+		}
+		if cc.Avatar != claims.Avatar {
+			t.Errorf("get custom avatar want %v but get %v", claims.Avatar, cc.Avatar)
+		}
+		if cc.Username != claims.Username {
+			t.Errorf("get custom username want %v but get %v", claims.Username, cc.Username)
+		}
+	})
+}
+
+func TestRedisCleanTenancyTokenCache(t *testing.T) {
+	redisAuth, err := NewRedisAuth(redis.NewUniversalClient(options))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	var tenancyId uint = 99
+	defer redisAuth.CleanTenancyTokenCache(tenancyId)
+	for _, cla := range []*MultiClaims{
+		newTestClaims(991, withTenancy(tenancyId), withAuthorityType(TenancyAuthority), withLoginType(LoginTypeWeb)),
+		newTestClaims(991, withTenancy(tenancyId), withAuthorityType(TenancyAuthority), withLoginType(LoginTypeApp)),
+		newTestClaims(992, withTenancy(tenancyId), withAuthorityType(TenancyAuthority), withLoginType(LoginTypeWeb)),
+	} {
+		if _, _, err := redisAuth.GenerateToken(cla); err != nil {
+			t.Fatalf("generate token %v", err)
+		}
+	}
+	t.Run("test tenancy tokens", func(t *testing.T) {
+		count, err := redisAuth.GetTenancyTokenCount(tenancyId)
+		if err != nil {
+			t.Fatalf("get tenancy token count %v", err)
+		}
+		if count != 3 {
+			t.Errorf("tenancy token count want 3 but get %d", count)
+		}
+		clas, err := redisAuth.GetTenancyTokens(tenancyId)
+		if err != nil {
+			t.Fatalf("get tenancy tokens %v", err)
+		}
+		if len(clas) != 3 {
+			t.Errorf("tenancy tokens want 3 but get %d", len(clas))
+		}
+	})
+	t.Run("test clean tenancy token cache", func(t *testing.T) {
+		if err := redisAuth.CleanTenancyTokenCache(tenancyId); err != nil {
+			t.Fatalf("clean tenancy token cache %v", err)
+		}
+		count, err := redisAuth.GetTenancyTokenCount(tenancyId)
+		if err != nil {
+			t.Fatalf("get tenancy token count %v", err)
+		}
+		if count != 0 {
+			t.Errorf("tenancy token count want 0 but get %d", count)
+		}
+		userCount, err := redisAuth.getUserTokenCount(TenancyAuthority, "991")
+		if err != nil {
+			t.Fatalf("user token count get %v", err)
+		}
+		if userCount != 0 {
+			t.Errorf("user token count want 0 but get %d", userCount)
+		}
+	})
+}
+
+func TestRedisCleanAuthorityTokenCache(t *testing.T) {
+	redisAuth, err := NewRedisAuth(redis.NewUniversalClient(options))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer redisAuth.CleanAuthorityTokenCache("302")
+	for _, cla := range []*MultiClaims{
+		newTestClaims(993, withAuthorityIds("301", "302")),
+		newTestClaims(994, withAuthorityIds("302")),
+	} {
+		if _, _, err := redisAuth.GenerateToken(cla); err != nil {
+			t.Fatalf("generate token %v", err)
+		}
+	}
+	t.Run("test authority tokens", func(t *testing.T) {
+		clas, err := redisAuth.GetAuthorityTokens("302")
+		if err != nil {
+			t.Fatalf("get authority tokens %v", err)
+		}
+		if len(clas) != 2 {
+			t.Errorf("authority tokens want 2 but get %d", len(clas))
+		}
+	})
+	t.Run("test clean authority token cache", func(t *testing.T) {
+		if err := redisAuth.CleanAuthorityTokenCache("301"); err != nil {
+			t.Fatalf("clean authority token cache %v", err)
+		}
+		clas, err := redisAuth.GetAuthorityTokens("302")
+		if err != nil {
+			t.Fatalf("get authority tokens %v", err)
+		}
+		if len(clas) != 1 {
+			t.Errorf("authority tokens want 1 but get %d", len(clas))
+		}
+		for _, cla := range clas {
+			if cla.Id != "994" {
+				t.Errorf("authority token user want 994 but get %s", cla.Id)
+			}
+		}
+	})
+}
+
+func TestRedisCleanAllUserTokenCache(t *testing.T) {
+	redisAuth, err := NewRedisAuth(redis.NewUniversalClient(options))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer redisAuth.CleanAllUserTokenCache("995")
+	for _, authorityType := range []int{AdminAuthority, GeneralAuthority} {
+		if _, _, err := redisAuth.GenerateToken(newTestClaims(995, withAuthorityType(authorityType))); err != nil {
+			t.Fatalf("generate token %v", err)
+		}
+	}
+	t.Run("test clean all user token cache", func(t *testing.T) {
+		if err := redisAuth.CleanAllUserTokenCache("995"); err != nil {
+			t.Fatalf("clean all user token cache %v", err)
+		}
+		for _, authorityType := range []int{AdminAuthority, GeneralAuthority} {
+			count, err := redisAuth.getUserTokenCount(authorityType, "995")
+			if err != nil {
+				t.Fatalf("user token count get %v", err)
+			}
+			if count != 0 {
+				t.Errorf("user token count of authority type %d want 0 but get %d", authorityType, count)
+			}
+		}
+	})
+}
+
+func TestRedisUnknownSessionIsRevoked(t *testing.T) {
+	redisAuth, err := NewRedisAuth(redis.NewUniversalClient(options))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	_, err = redisAuth.GetMultiClaims("unknown-session-token")
+	if !errors.Is(err, ErrTokenInvalid) {
+		t.Fatalf("get custom claims err want '%v' but get '%v'", ErrTokenInvalid, err)
+	}
+	vErr := AsVerifyError(err)
+	if vErr.Code != ErrCodeRevoked || !strings.Contains(vErr.WWWAuthenticate(), "invalid_token") {
+		t.Errorf("verify error want %s and invalid_token but get %s %s", ErrCodeRevoked, vErr.Code, vErr.WWWAuthenticate())
+	}
+}
+
+func TestRedisCleanLegacyUserTokenCache(t *testing.T) {
+	client := redis.NewUniversalClient(options)
+	redisAuth, err := NewRedisAuth(client)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer redisAuth.CleanAllUserTokenCache("994")
+	claims := newTestClaims(994, withAuthorityType(GeneralAuthority))
+	token, _, err := redisAuth.GenerateToken(claims)
+	if err != nil {
+		t.Fatalf("generate token %v", err)
+	}
+	// the session looks like one created before the user id and authority indexes.
+	client.Del(context.Background(), getUserIdPrefixKey("994"), getAuthorityPrefixKey("999"))
+
+	t.Run("test backfill session indexes", func(t *testing.T) {
+		if err := redisAuth.BackfillSessionIndexes(context.Background()); err != nil {
+			t.Fatalf("backfill session indexes %v", err)
+		}
+		tokens, err := redisAuth.GetAuthorityTokens("999")
+		if err != nil {
+			t.Fatalf("get authority tokens %v", err)
+		}
+		if _, ok := tokens[token]; !ok {
+			t.Errorf("authority tokens want %s but get %v", token, tokens)
+		}
+		client.Del(context.Background(), getUserIdPrefixKey("994"))
+	})
+	t.Run("test clean all user token cache without user id index", func(t *testing.T) {
+		if err := redisAuth.CleanAllUserTokenCache("994"); err != nil {
+			t.Fatalf("clean all user token cache %v", err)
+		}
+		if _, err := redisAuth.GetMultiClaims(token); err == nil {
+			t.Error("get custom claims of the legacy session want error")
+		}
+	})
+}
+
+func TestRedisCsrfSecret(t *testing.T) {
+	redisAuth, err := NewRedisAuth(redis.NewUniversalClient(options))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer redisAuth.CleanAllUserTokenCache("996")
+	token, _, err := redisAuth.GenerateToken(newTestClaims(996))
+	if err != nil {
+		t.Fatalf("generate token %v", err)
+	}
+	t.Run("test csrf secret", func(t *testing.T) {
+		if _, err := redisAuth.GetCsrfSecret(token); !errors.Is(err, ErrCsrfInvalid) {
+			t.Errorf("get csrf secret want %v but get %v", ErrCsrfInvalid, err)
+		}
+		secret, err := redisAuth.SetCsrfSecret(token)
+		if err != nil {
+			t.Fatalf("set csrf secret %v", err)
+		}
+		got, err := redisAuth.GetCsrfSecret(token)
+		if err != nil {
+			t.Fatalf("get csrf secret %v", err)
+		}
+		if got != secret {
+			t.Errorf("get csrf secret want %s but get %s", secret, got)
+		}
+		if _, err := redisAuth.GetMultiClaims(token); err != nil {
+			t.Errorf("get multi claims %v", err)
+		}
+	})
+	t.Run("test csrf secret deleted with session", func(t *testing.T) {
+		if err := redisAuth.DelUserTokenCache(token); err != nil {
+			t.Fatalf("del user token cache %v", err)
+		}
+		if _, err := redisAuth.GetCsrfSecret(token); !errors.Is(err, ErrCsrfInvalid) {
+			t.Errorf("get csrf secret want %v but get %v", ErrCsrfInvalid, err)
+		}
+		if _, err := redisAuth.SetCsrfSecret(token); !errors.Is(err, ErrTokenInvalid) {
+			t.Errorf("set csrf secret want %v but get %v", ErrTokenInvalid, err)
+		}
+	})
+}
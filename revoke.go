@@ -0,0 +1,65 @@
+package multi
+
+import "sync"
+
+// DefaultRevokeWatcher is notified by the stateful drivers when a session is removed.
+var DefaultRevokeWatcher = NewRevokeWatcher()
+
+// RevokeWatcher notifies the long-lived connections (e.g. websocket)
+// when their session is revoked by DelUserTokenCache, CleanUserTokenCache and so on.
+// The sessions are watched by their id, i.e. the token hashed by the Hasher of the driver,
+// see WatchRevoked.
+type RevokeWatcher struct {
+	mu        sync.Mutex
+	next      uint64
+	listeners map[string]map[uint64]func()
+}
+
+// NewRevokeWatcher
+func NewRevokeWatcher() *RevokeWatcher {
+	return &RevokeWatcher{
+		listeners: map[string]map[uint64]func(){},
+	}
+}
+
+// Watch calls fn once when the session of the id is revoked.
+// The returned cancel func should be called when the connection is closed.
+func (rw *RevokeWatcher) Watch(token string, fn func()) (cancel func()) {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	rw.next++
+	id := rw.next
+	if rw.listeners[token] == nil {
+		rw.listeners[token] = map[uint64]func(){}
+	}
+	rw.listeners[token][id] = fn
+	return func() {
+		rw.mu.Lock()
+		defer rw.mu.Unlock()
+		delete(rw.listeners[token], id)
+		if len(rw.listeners[token]) == 0 {
+			delete(rw.listeners, token)
+		}
+	}
+}
+
+// Notify calls and removes the listeners of the session id.
+func (rw *RevokeWatcher) Notify(token string) {
+	rw.mu.Lock()
+	fns := rw.listeners[token]
+	delete(rw.listeners, token)
+	rw.mu.Unlock()
+	for _, fn := range fns {
+		fn()
+	}
+}
+
+// WatchRevoked calls fn once when the session of the token is revoked by the driver,
+// the token is hashed by the Hasher of the driver as the drivers notify the session ids.
+// The returned cancel func should be called when the connection is closed.
+func WatchRevoked(driver interface{}, token string, fn func()) (cancel func()) {
+	if d, ok := driver.(interface{ sessionId(token string) string }); ok {
+		token = d.sessionId(token)
+	}
+	return DefaultRevokeWatcher.Watch(token, fn)
+}
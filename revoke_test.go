@@ -0,0 +1,43 @@
+package multi
+
+import (
+	"testing"
+)
+
+func TestRevokeWatcher(t *testing.T) {
+	t.Run("Test notify and cancel", func(t *testing.T) {
+		rw := NewRevokeWatcher()
+		var notified, canceled int
+		rw.Watch("token", func() { notified++ })
+		cancel := rw.Watch("token", func() { canceled++ })
+		cancel()
+		rw.Notify("token")
+		rw.Notify("token")
+		if notified != 1 {
+			t.Errorf("notified want 1 but get %d", notified)
+		}
+		if canceled != 0 {
+			t.Errorf("canceled want 0 but get %d", canceled)
+		}
+	})
+}
+
+func TestLocalRevokeNotify(t *testing.T) {
+	t.Run("Test del user token cache notify", func(t *testing.T) {
+		token, _, err := localAuth.GenerateToken(newTestClaims(996))
+		if err != nil {
+			t.Fatalf("generate token %v", err)
+		}
+		revoked := make(chan struct{}, 1)
+		cancel := DefaultRevokeWatcher.Watch(token, func() { revoked <- struct{}{} })
+		defer cancel()
+		if err := localAuth.DelUserTokenCache(token); err != nil {
+			t.Fatalf("del user token cache %v", err)
+		}
+		select {
+		case <-revoked:
+		default:
+			t.Error("revoked session is not notified")
+		}
+	})
+}
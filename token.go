@@ -2,41 +2,84 @@ package multi
 
 import (
 	"bytes"
+	"crypto/rand"
 	"encoding/base64"
+	"encoding/binary"
 	"fmt"
 
 	"github.com/bwmarrin/snowflake"
-	uuid "github.com/satori/go.uuid"
-	"github.com/snowlyg/helper/dir"
 )
 
 var (
-	sep    = []byte(".")
 	pad    = []byte("=")
 	padStr = string(pad)
 )
 
-// GetToken 雪花算法,支持分布式集群方式
-func GetToken() (string, error) {
-	v4, err := uuid.NewV4()
-	if err != nil {
+// TokenGenerator generates the opaque tokens of the stateful drivers.
+type TokenGenerator interface {
+	Generate() (string, error)
+}
+
+// TokenGeneratorFunc is the interface-as-function shortcut for a TokenGenerator.
+type TokenGeneratorFunc func() (string, error)
+
+// Generate completes the TokenGenerator interface.
+// It calls itself.
+func (fn TokenGeneratorFunc) Generate() (string, error) {
+	return fn()
+}
+
+// RandomTokenGenerator generates the tokens of 256 bits from crypto/rand.
+type RandomTokenGenerator struct{}
+
+// Generate
+func (RandomTokenGenerator) Generate() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
 		return "", fmt.Errorf("mutil: create token %w", err)
 	}
-	node, err := snowflake.NewNode(1)
+	return string(Base64Encode(buf)), nil
+}
+
+// SnowflakeTokenGenerator generates the tokens of a snowflake id followed by 192 random bits,
+// the tokens are unique in the cluster if every server has its own node id.
+type SnowflakeTokenGenerator struct {
+	node *snowflake.Node
+}
+
+// NewSnowflakeTokenGenerator returns the snowflake token generator of the node, the node id is 0 to 1023.
+func NewSnowflakeTokenGenerator(nodeId int64) (*SnowflakeTokenGenerator, error) {
+	node, err := snowflake.NewNode(nodeId)
 	if err != nil {
+		return nil, fmt.Errorf("mutil: create snowflake node %w", err)
+	}
+	return &SnowflakeTokenGenerator{node: node}, nil
+}
+
+// Generate
+func (g *SnowflakeTokenGenerator) Generate() (string, error) {
+	buf := make([]byte, 32)
+	binary.BigEndian.PutUint64(buf, uint64(g.node.Generate().Int64()))
+	if _, err := rand.Read(buf[8:]); err != nil {
 		return "", fmt.Errorf("mutil: create token %w", err)
 	}
+	return string(Base64Encode(buf)), nil
+}
+
+// DefaultTokenGenerator is used by GetToken and the drivers without a Generator.
+var DefaultTokenGenerator TokenGenerator = RandomTokenGenerator{}
 
-	// 混入两个时间，防止并发 token 重复
-	nodeBytes, _ := dir.Md5Byte(Base64Encode(node.Generate().Bytes()))
-	uuidBytes, _ := dir.Md5Byte(Base64Encode(joinParts(Base64Encode(v4.Bytes()), []byte(nodeBytes))))
-	token := joinParts(Base64Encode([]byte(uuidBytes)), Base64Encode([]byte(nodeBytes)))
-	return string(Base64Encode([]byte(token))), nil
+// generatorOf returns the generator, or DefaultTokenGenerator if it is nil.
+func generatorOf(g TokenGenerator) TokenGenerator {
+	if g == nil {
+		return DefaultTokenGenerator
+	}
+	return g
 }
 
-// joinParts
-func joinParts(parts ...[]byte) []byte {
-	return bytes.Join(parts, sep)
+// GetToken generates the token by DefaultTokenGenerator.
+func GetToken() (string, error) {
+	return DefaultTokenGenerator.Generate()
 }
 
 // Base64Encode
@@ -2,11 +2,37 @@ package multi
 
 import (
 	"bytes"
+	"fmt"
 	"testing"
 
+	"github.com/bwmarrin/snowflake"
+	uuid "github.com/satori/go.uuid"
 	"github.com/snowlyg/helper/arr"
+	"github.com/snowlyg/helper/dir"
 )
 
+// joinParts joins the parts of the legacy tokens.
+func joinParts(parts ...[]byte) []byte {
+	return bytes.Join(parts, []byte("."))
+}
+
+// legacyGetToken is the former GetToken, it is kept to benchmark the generators against.
+func legacyGetToken() (string, error) {
+	v4, err := uuid.NewV4()
+	if err != nil {
+		return "", fmt.Errorf("mutil: create token %w", err)
+	}
+	node, err := snowflake.NewNode(1)
+	if err != nil {
+		return "", fmt.Errorf("mutil: create token %w", err)
+	}
+
+	nodeBytes, _ := dir.Md5Byte(Base64Encode(node.Generate().Bytes()))
+	uuidBytes, _ := dir.Md5Byte(Base64Encode(joinParts(Base64Encode(v4.Bytes()), []byte(nodeBytes))))
+	token := joinParts(Base64Encode([]byte(uuidBytes)), Base64Encode([]byte(nodeBytes)))
+	return string(Base64Encode([]byte(token))), nil
+}
+
 func TestGetToken(t *testing.T) {
 	t.Run("Test generate token.", func(t *testing.T) {
 		token, err := GetToken()
@@ -19,6 +45,38 @@ func TestGetToken(t *testing.T) {
 	})
 }
 
+func TestTokenGenerators(t *testing.T) {
+	sf, err := NewSnowflakeTokenGenerator(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, generator := range map[string]TokenGenerator{
+		"random":    RandomTokenGenerator{},
+		"snowflake": sf,
+	} {
+		t.Run("Test "+name+" generator.", func(t *testing.T) {
+			seen := map[string]bool{}
+			for i := 0; i < 1000; i++ {
+				token, err := generator.Generate()
+				if err != nil {
+					t.Fatal(err)
+				}
+				raw, err := Base64Decode([]byte(token))
+				if err != nil || len(raw) != 32 {
+					t.Fatalf("token want 32 bytes but get %d %v", len(raw), err)
+				}
+				if seen[token] {
+					t.Fatalf("token is repeat")
+				}
+				seen[token] = true
+			}
+		})
+	}
+	if _, err := NewSnowflakeTokenGenerator(1024); err == nil {
+		t.Error("snowflake node id 1024 want error")
+	}
+}
+
 func TestJoinParts(t *testing.T) {
 	t.Run("Test join parts.", func(t *testing.T) {
 		afterJoin := joinParts([]byte("header"), []byte("footer"))
@@ -65,3 +123,27 @@ func BenchmarkGetToken(b *testing.B) {
 type Token struct {
 	arr.CheckArrayType
 }
+
+func BenchmarkTokenGenerator(b *testing.B) {
+	sf, err := NewSnowflakeTokenGenerator(1)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, bench := range []struct {
+		name      string
+		generator TokenGenerator
+	}{
+		{name: "legacy", generator: TokenGeneratorFunc(legacyGetToken)},
+		{name: "random", generator: RandomTokenGenerator{}},
+		{name: "snowflake", generator: sf},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := bench.generator.Generate(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
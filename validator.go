@@ -0,0 +1,170 @@
+package multi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/patrickmn/go-cache"
+)
+
+var (
+	ErrTokenBlocked     = errors.New("TOKEN IS BLOCKED")
+	ErrClaimsUnexpected = errors.New("claims are unexpected")
+)
+
+// Blocklist is a TokenValidator which rejects the blocked tokens until they expire,
+// the blocked tokens are kept in Redis when Client is set, otherwise in the local Cache.
+// Hasher hashes the blocked tokens at rest, they are stored verbatim if it is nil.
+type Blocklist struct {
+	Cache  *cache.Cache
+	Client redis.UniversalClient
+	Hasher *TokenHasher
+}
+
+// NewBlocklist returns the blocklist backed by the local cache.
+func NewBlocklist() *Blocklist {
+	return &Blocklist{
+		Cache: cache.New(cache.NoExpiration, 24*time.Minute),
+	}
+}
+
+// NewRedisBlocklist returns the blocklist backed by Redis,
+// the blocked tokens are shared by the instances.
+func NewRedisBlocklist(client redis.UniversalClient) *Blocklist {
+	return &Blocklist{
+		Client: client,
+	}
+}
+
+// Block blocks the token, it never expires if expiration is 0.
+func (b *Blocklist) Block(token string, expiration time.Duration) error {
+	if b.Client != nil {
+		if _, err := b.Client.Set(context.Background(), b.key(token), 1, expiration).Result(); err != nil {
+			return fmt.Errorf("block token redis set %w", err)
+		}
+		return nil
+	}
+	if expiration == 0 {
+		expiration = cache.NoExpiration
+	}
+	b.Cache.Set(b.key(token), struct{}{}, expiration)
+	return nil
+}
+
+// Unblock removes the token from the blocklist.
+func (b *Blocklist) Unblock(token string) error {
+	if b.Client != nil {
+		if _, err := b.Client.Del(context.Background(), b.key(token)).Result(); err != nil {
+			return fmt.Errorf("unblock token redis del %w", err)
+		}
+		return nil
+	}
+	b.Cache.Delete(b.key(token))
+	return nil
+}
+
+// key
+func (b *Blocklist) key(token string) string {
+	return GtSessionBlockPrefix + hashToken(b.Hasher, token)
+}
+
+// IsBlocked reports whether the token is blocked.
+func (b *Blocklist) IsBlocked(token string) (bool, error) {
+	if b.Client != nil {
+		count, err := b.Client.Exists(context.Background(), b.key(token)).Result()
+		if err != nil {
+			return false, internal(fmt.Errorf("is blocked token redis exists %w", err))
+		}
+		return count > 0, nil
+	}
+	_, found := b.Cache.Get(b.key(token))
+	return found, nil
+}
+
+// ValidateToken rejects the blocked token with ErrTokenBlocked.
+func (b *Blocklist) ValidateToken(token []byte, err error) error {
+	if err != nil {
+		return err
+	}
+	blocked, err := b.IsBlocked(string(token))
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return ErrTokenBlocked
+	}
+	return nil
+}
+
+// Expected is a ClaimsValidator which rejects the claims unlike the expected values
+// with ErrClaimsUnexpected, the zero values are not checked.
+type Expected struct {
+	TenancyId      uint
+	AuthorityTypes []int
+	LoginTypes     []int
+	Issuer         string
+}
+
+// ValidateToken completes the TokenValidator interface, the token is checked by ValidateClaims.
+func (e Expected) ValidateToken(token []byte, err error) error {
+	return err
+}
+
+// ValidateClaims
+func (e Expected) ValidateClaims(token []byte, claims *MultiClaims, err error) error {
+	if err != nil {
+		return err
+	}
+	if e.TenancyId > 0 && claims.TenancyId != e.TenancyId {
+		return fmt.Errorf("%w: tenancy id %d", ErrClaimsUnexpected, claims.TenancyId)
+	}
+	if len(e.AuthorityTypes) > 0 && !containsInt(e.AuthorityTypes, claims.AuthorityType) {
+		return fmt.Errorf("%w: authority type %d", ErrClaimsUnexpected, claims.AuthorityType)
+	}
+	if len(e.LoginTypes) > 0 && !containsInt(e.LoginTypes, claims.LoginType) {
+		return fmt.Errorf("%w: login type %d", ErrClaimsUnexpected, claims.LoginType)
+	}
+	if e.Issuer != "" && claims.Issuer != e.Issuer {
+		return fmt.Errorf("%w: issuer %q", ErrClaimsUnexpected, claims.Issuer)
+	}
+	return nil
+}
+
+// containsInt
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Leeway is a ClaimsValidator which tolerates the clock skew,
+// the claims expired within the leeway are still valid.
+type Leeway time.Duration
+
+// ValidateToken completes the TokenValidator interface, the token is checked by ValidateClaims.
+func (l Leeway) ValidateToken(token []byte, err error) error {
+	return err
+}
+
+// ValidateClaims drops the ErrClaimsExpired failure of the claims expired within the leeway,
+// the leeway is counted from the time the claims were validated at, i.e. by the clock of the driver.
+func (l Leeway) ValidateClaims(token []byte, claims *MultiClaims, err error) error {
+	var vErr *ValidationError
+	if !errors.As(err, &vErr) || vErr.Errors&ValidationErrorExpired == 0 {
+		return err
+	}
+	at := vErr.At
+	if at.IsZero() {
+		at = DefaultClock.Now()
+	}
+	if !claims.VerifyExpiresAt(at.Add(-time.Duration(l)).Unix(), false) {
+		return err
+	}
+	return vErr.without(ValidationErrorExpired, ErrClaimsExpired)
+}
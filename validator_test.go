@@ -0,0 +1,119 @@
+package multi
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func TestBlocklist(t *testing.T) {
+	for name, blocklist := range map[string]*Blocklist{
+		"local": NewBlocklist(),
+		"redis": NewRedisBlocklist(redis.NewUniversalClient(options)),
+	} {
+		t.Run("test "+name+" blocklist", func(t *testing.T) {
+			token := "blocklist-" + name
+			if err := blocklist.ValidateToken([]byte(token), nil); err != nil {
+				t.Errorf("validate token want nil but get %v", err)
+			}
+			if err := blocklist.Block(token, time.Minute); err != nil {
+				t.Fatalf("block token %v", err)
+			}
+			if err := blocklist.ValidateToken([]byte(token), nil); !errors.Is(err, ErrTokenBlocked) {
+				t.Errorf("validate token want %v but get %v", ErrTokenBlocked, err)
+			}
+			if err := blocklist.Unblock(token); err != nil {
+				t.Fatalf("unblock token %v", err)
+			}
+			if blocked, _ := blocklist.IsBlocked(token); blocked {
+				t.Error("token should be unblocked")
+			}
+		})
+	}
+}
+
+func TestExpected(t *testing.T) {
+	cla := newTestClaims(998, withTenancy(3), withAuthorityType(TenancyAuthority), withLoginType(LoginTypeApp))
+	cla.Issuer = "multi"
+	tests := []struct {
+		name     string
+		expected Expected
+		err      error
+	}{
+		{name: "any", expected: Expected{}},
+		{name: "match", expected: Expected{TenancyId: 3, AuthorityTypes: []int{cla.AuthorityType}, LoginTypes: []int{LoginTypeWeb, LoginTypeApp}, Issuer: "multi"}},
+		{name: "tenancy", expected: Expected{TenancyId: 4}, err: ErrClaimsUnexpected},
+		{name: "authority type", expected: Expected{AuthorityTypes: []int{NoneAuthority}}, err: ErrClaimsUnexpected},
+		{name: "login type", expected: Expected{LoginTypes: []int{LoginTypeWeb}}, err: ErrClaimsUnexpected},
+		{name: "issuer", expected: Expected{Issuer: "other"}, err: ErrClaimsUnexpected},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := ValidateClaims(nil, cla, test.expected); !errors.Is(err, test.err) {
+				t.Errorf("validate claims want %v but get %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestLeeway(t *testing.T) {
+	cla := newTestClaims(998)
+	cla.ExpiresAt = time.Now().Add(-30 * time.Second).Unix()
+	t.Run("test expired within leeway", func(t *testing.T) {
+		if err := ValidateClaims(nil, cla, Leeway(time.Minute)); err != nil {
+			t.Errorf("validate claims want nil but get %v", err)
+		}
+	})
+	t.Run("test expired over leeway", func(t *testing.T) {
+		if err := ValidateClaims(nil, cla, Leeway(10*time.Second)); !errors.Is(err, ErrClaimsExpired) {
+			t.Errorf("validate claims want %v but get %v", ErrClaimsExpired, err)
+		}
+	})
+	t.Run("test other failures kept", func(t *testing.T) {
+		invalid := *cla
+		invalid.Username = ""
+		err := ValidateClaims(nil, &invalid, Leeway(time.Minute))
+		if errors.Is(err, ErrClaimsExpired) || !errors.Is(err, ErrClaimsMissingUsername) {
+			t.Errorf("validate claims want only %v but get %v", ErrClaimsMissingUsername, err)
+		}
+	})
+}
+
+func TestValidateRequest(t *testing.T) {
+	errIP := errors.New("ip is not allowed")
+	allowIP := RequestValidatorFunc(func(token []byte, claims *MultiClaims, req RequestInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if req.IP != "127.0.0.1" {
+			return errIP
+		}
+		return nil
+	})
+	cla := newTestClaims(998)
+	t.Run("test request allowed", func(t *testing.T) {
+		req := RequestInfo{Method: "GET", Path: "/", IP: "127.0.0.1"}
+		if err := ValidateRequest(nil, cla, req, allowIP); err != nil {
+			t.Errorf("validate request want nil but get %v", err)
+		}
+	})
+	t.Run("test request rejected", func(t *testing.T) {
+		req := RequestInfo{Method: "GET", Path: "/", IP: "10.0.0.1"}
+		if err := ValidateRequest(nil, cla, req, allowIP); !errors.Is(err, errIP) {
+			t.Errorf("validate request want %v but get %v", errIP, err)
+		}
+	})
+	t.Run("test empty request rejected", func(t *testing.T) {
+		if err := ValidateClaims(nil, cla, allowIP); !errors.Is(err, errIP) {
+			t.Errorf("validate claims want %v but get %v", errIP, err)
+		}
+	})
+	t.Run("test after claims validators", func(t *testing.T) {
+		req := RequestInfo{Method: "GET", Path: "/", IP: "127.0.0.1"}
+		if err := ValidateRequest(nil, cla, req, allowIP, Expected{TenancyId: 99}); !errors.Is(err, ErrClaimsUnexpected) {
+			t.Errorf("validate request want %v but get %v", ErrClaimsUnexpected, err)
+		}
+	})
+}
@@ -0,0 +1,154 @@
+package multi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DriverOf returns the driver, or the global AuthDriver when the driver is nil
+// and AuthDriver stores the claims type T. nil is returned if there is no driver.
+func DriverOf[T Claims](driver AuthenticationOf[T]) AuthenticationOf[T] {
+	if driver != nil {
+		return driver
+	}
+	if driver, ok := any(AuthDriver).(AuthenticationOf[T]); ok {
+		return driver
+	}
+	return nil
+}
+
+// claimsFinder is implemented by the drivers of this package,
+// VerifyRequest dispatches their EventVerifyFailed events with the request instead of GetMultiClaims.
+type claimsFinder[T Claims] interface {
+	findMultiClaims(token string) (T, error)
+	verifyFailed(token string, req RequestInfo, err error)
+}
+
+// VerifyRequest verifies the token of a request and returns its claims,
+// it is the framework independent part of the verifiers of the adapters.
+// The token validators run first, then the claims are decoded by the driver, see DriverOf,
+// and validated by ValidateRequestAt at the time of the driver clock.
+// Every failure is dispatched as the EventVerifyFailed event with the request by the drivers of this package.
+// The error is a *VerifyError which wraps the cause.
+func VerifyRequest[T Claims](driver AuthenticationOf[T], token []byte, req RequestInfo, validators ...TokenValidator) (T, error) {
+	driver = DriverOf(driver)
+	claims, err := verifyRequest(driver, token, req, validators...)
+	if err != nil {
+		if d, ok := driver.(claimsFinder[T]); ok {
+			d.verifyFailed(string(token), req, err)
+		}
+		var empty T
+		return empty, AsVerifyError(err)
+	}
+	return claims, nil
+}
+
+// verifyRequest
+func verifyRequest[T Claims](driver AuthenticationOf[T], token []byte, req RequestInfo, validators ...TokenValidator) (T, error) {
+	var empty T
+	if len(token) == 0 {
+		return empty, ErrEmptyToken
+	}
+	var err error
+	for _, validator := range validators {
+		// A token validator can skip the builtin validation and return a nil error,
+		// in that case the previous error is skipped.
+		if err = validator.ValidateToken(token, err); err != nil {
+			return empty, err
+		}
+	}
+	if driver == nil {
+		return empty, ErrDriverNil
+	}
+	var claims T
+	if d, ok := driver.(claimsFinder[T]); ok {
+		claims, err = d.findMultiClaims(string(token))
+	} else {
+		claims, err = driver.GetMultiClaims(string(token))
+	}
+	if err != nil {
+		return empty, err
+	}
+	// the claims accepted within the leeway of the driver are not rejected again.
+	if d, ok := driver.(interface{ leeway() time.Duration }); ok && d.leeway() > 0 {
+		validators = append([]TokenValidator{Leeway(d.leeway())}, validators...)
+	}
+	if err := ValidateRequestAt(DriverClock(driver).Now(), token, claims, req, validators...); err != nil {
+		return empty, err
+	}
+	return claims, nil
+}
+
+// ProblemJSON returns the problem+json body of the error responses, see Problem.
+func (e *VerifyError) ProblemJSON() []byte {
+	body, _ := json.Marshal(e.Problem())
+	return body
+}
+
+// UserId returns the user id of the claims, 0 is returned for nil claims or a non numeric id.
+func (c *MultiClaims) UserId() uint {
+	if c == nil {
+		return 0
+	}
+	id, err := strconv.ParseUint(c.Id, 10, 0)
+	if err != nil {
+		return 0
+	}
+	return uint(id)
+}
+
+// AuthorityIds returns the authority ids of the claims, nil is returned for nil claims.
+func (c *MultiClaims) AuthorityIds() []string {
+	if c == nil {
+		return nil
+	}
+	return getAuthorityIds(c.AuthorityId)
+}
+
+// ParseHeaderToken returns the token of the header value of form "{scheme} {token}",
+// the scheme is case-insensitive and may be empty to read the whole value as the token.
+// An empty string is returned if the value is not of the form.
+func ParseHeaderToken(value, scheme string) string {
+	if scheme == "" {
+		return strings.TrimSpace(value)
+	}
+	parts := strings.Fields(value)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], scheme) {
+		return ""
+	}
+	return parts[1]
+}
+
+// IssueToken generates the token of the claims and binds the csrf secret to its session,
+// it is the framework independent part of IssueTokenCookie of the adapters.
+// When the secret can not be bound, the session is deleted if this call created it,
+// a session reused by the driver for the equal claims may belong to another device and is kept.
+func IssueToken[T Claims](driver AuthenticationOf[T], claims T) (token, csrfSecret string, err error) {
+	if driver == nil {
+		return "", "", ErrDriverNil
+	}
+	existing, err := driver.GetTokenByClaims(claims)
+	if err != nil && !errors.Is(err, ErrForJwt) {
+		return "", "", err
+	}
+	token, _, err = driver.GenerateToken(claims)
+	if err != nil {
+		return "", "", err
+	}
+	csrfSecret, err = driver.SetCsrfSecret(token)
+	if err != nil {
+		if token == existing {
+			return "", "", err
+		}
+		// the jwt driver keeps no session to delete.
+		if delErr := driver.DelUserTokenCache(token); delErr != nil && !errors.Is(delErr, ErrForJwt) {
+			return "", "", fmt.Errorf("%w, and the new session is not deleted: %v", err, delErr)
+		}
+		return "", "", err
+	}
+	return token, csrfSecret, nil
+}
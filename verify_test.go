@@ -0,0 +1,194 @@
+package multi
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifyRequest(t *testing.T) {
+	auth := NewLocalAuth()
+	token, _, err := auth.GenerateToken(newTestClaims(981))
+	if err != nil {
+		t.Fatalf("generate token %v", err)
+	}
+	defer auth.DelUserTokenCache(token)
+	expired := newTestClaims(982, func(c *MultiClaims) {
+		c.ExpiresAt = time.Now().Add(-time.Hour).Unix()
+	})
+	expiredToken, _, err := auth.GenerateToken(expired)
+	if err != nil {
+		t.Fatalf("generate token %v", err)
+	}
+	defer auth.DelUserTokenCache(expiredToken)
+	rejected := errors.New("ip not allowed")
+
+	tests := []struct {
+		name       string
+		driver     Authentication
+		token      string
+		validators []TokenValidator
+		err        error
+		code       string
+	}{
+		{name: "empty token", driver: auth, err: ErrEmptyToken, code: ErrCodeMissing},
+		{name: "token validator", driver: auth, token: token, validators: []TokenValidator{TokenValidatorFunc(func(token []byte, err error) error { return rejected })}, err: rejected},
+		{name: "request validator", driver: auth, token: token, validators: []TokenValidator{RequestValidatorFunc(func(token []byte, claims *MultiClaims, req RequestInfo, err error) error {
+			if req.IP != "10.0.0.1" {
+				return rejected
+			}
+			return err
+		})}},
+		{name: "no driver", token: token, err: ErrDriverNil, code: ErrCodeInternal},
+		{name: "unknown session", driver: auth, token: "unknown-session-token", err: ErrTokenInvalid, code: ErrCodeRevoked},
+		{name: "expired claims", driver: auth, token: expiredToken, err: ErrClaimsExpired, code: ErrCodeExpired},
+		{name: "valid", driver: auth, token: token},
+	}
+	defer func(driver Authentication) { AuthDriver = driver }(AuthDriver)
+	AuthDriver = nil
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			claims, err := VerifyRequest(test.driver, []byte(test.token), RequestInfo{IP: "10.0.0.1"}, test.validators...)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("verify request want %v but get %v", test.err, err)
+			}
+			if err == nil {
+				if claims.Id != "981" {
+					t.Errorf("claims id want 981 but get %s", claims.Id)
+				}
+				return
+			}
+			var vErr *VerifyError
+			if !errors.As(err, &vErr) {
+				t.Fatalf("verify request error want *VerifyError but get %T", err)
+			}
+			if test.code != "" && vErr.Code != test.code {
+				t.Errorf("code want %s but get %s", test.code, vErr.Code)
+			}
+		})
+	}
+}
+
+func TestDriverOf(t *testing.T) {
+	defer func(driver Authentication) { AuthDriver = driver }(AuthDriver)
+	AuthDriver = NewLocalAuth()
+	if DriverOf[*MultiClaims](nil) != AuthDriver {
+		t.Error("driver of nil want the global driver")
+	}
+	if DriverOf[*avatarClaims](nil) != nil {
+		t.Error("driver of nil want nil when the global driver stores other claims")
+	}
+	jwtAuth := NewJwtAuth(nil)
+	if DriverOf[*MultiClaims](jwtAuth) != Authentication(jwtAuth) {
+		t.Error("driver of the driver want the driver")
+	}
+}
+
+func TestClaimsAccessors(t *testing.T) {
+	var nilClaims *MultiClaims
+	if nilClaims.UserId() != 0 || nilClaims.AuthorityIds() != nil {
+		t.Error("accessors of nil claims want zero values")
+	}
+	claims := newTestClaims(983, withAuthorityIds("301", "302"))
+	if claims.UserId() != 983 {
+		t.Errorf("user id want 983 but get %d", claims.UserId())
+	}
+	if ids := claims.AuthorityIds(); len(ids) != 2 || ids[1] != "302" {
+		t.Errorf("authority ids want [301 302] but get %v", ids)
+	}
+}
+
+// issueFailingDriver fails to bind the csrf secret,
+// it reuses the reuse token like the redis driver does for the equal claims if it is set.
+type issueFailingDriver struct {
+	Authentication
+	reuse  string
+	token  string
+	delErr error
+}
+
+var errCsrfSecret = errors.New("set csrf secret failed")
+
+func (d *issueFailingDriver) GetTokenByClaims(claims *MultiClaims) (string, error) {
+	if d.reuse != "" {
+		return d.reuse, nil
+	}
+	return d.Authentication.GetTokenByClaims(claims)
+}
+
+func (d *issueFailingDriver) GenerateToken(claims *MultiClaims) (string, int64, error) {
+	if d.reuse != "" {
+		d.token = d.reuse
+		return d.reuse, claims.ExpiresAt, nil
+	}
+	token, expiresAt, err := d.Authentication.GenerateToken(claims)
+	d.token = token
+	return token, expiresAt, err
+}
+
+func (d *issueFailingDriver) SetCsrfSecret(token string) (string, error) {
+	return "", errCsrfSecret
+}
+
+func (d *issueFailingDriver) DelUserTokenCache(token string) error {
+	if d.delErr != nil {
+		return d.delErr
+	}
+	return d.Authentication.DelUserTokenCache(token)
+}
+
+func TestIssueToken(t *testing.T) {
+	auth := NewLocalAuth()
+	for name, driver := range map[string]Authentication{"local": auth, "jwt": NewJwtAuth(nil)} {
+		t.Run(name, func(t *testing.T) {
+			token, secret, err := IssueToken(driver, newTestClaims(984))
+			if err != nil {
+				t.Fatalf("issue token %v", err)
+			}
+			defer driver.DelUserTokenCache(token)
+			if got, _ := driver.GetCsrfSecret(token); got == "" || got != secret {
+				t.Errorf("csrf secret want %s but get %s", secret, got)
+			}
+		})
+	}
+
+	t.Run("new session deleted", func(t *testing.T) {
+		failing := &issueFailingDriver{Authentication: auth}
+		if _, _, err := IssueToken[*MultiClaims](failing, newTestClaims(985)); !errors.Is(err, errCsrfSecret) {
+			t.Fatalf("issue token want %v but get %v", errCsrfSecret, err)
+		}
+		if _, err := auth.GetMultiClaims(failing.token); err == nil {
+			t.Error("session created by the failed issue want deleted")
+		}
+	})
+
+	t.Run("reused session kept", func(t *testing.T) {
+		existing, _, err := auth.GenerateToken(newTestClaims(986))
+		if err != nil {
+			t.Fatalf("generate token %v", err)
+		}
+		defer auth.DelUserTokenCache(existing)
+		failing := &issueFailingDriver{Authentication: auth, reuse: existing}
+		if _, _, err := IssueToken[*MultiClaims](failing, newTestClaims(986)); !errors.Is(err, errCsrfSecret) {
+			t.Fatalf("issue token want %v but get %v", errCsrfSecret, err)
+		}
+		if _, err := auth.GetMultiClaims(existing); err != nil {
+			t.Errorf("session of another device want kept but get %v", err)
+		}
+	})
+
+	t.Run("delete error reported", func(t *testing.T) {
+		delErr := errors.New("del user token cache failed")
+		failing := &issueFailingDriver{Authentication: auth, delErr: delErr}
+		_, _, err := IssueToken[*MultiClaims](failing, newTestClaims(987))
+		defer auth.DelUserTokenCache(failing.token)
+		if !errors.Is(err, errCsrfSecret) || !strings.Contains(err.Error(), delErr.Error()) {
+			t.Errorf("issue token want %v with the delete error but get %v", errCsrfSecret, err)
+		}
+	})
+
+	if _, _, err := IssueToken[*MultiClaims](nil, newTestClaims(988)); !errors.Is(err, ErrDriverNil) {
+		t.Errorf("issue token without driver want %v but get %v", ErrDriverNil, err)
+	}
+}